@@ -1,24 +1,44 @@
 package main
 
 import (
+	"context"
+	"emailgo/internal/config"
+	"emailgo/internal/domain/apikey"
 	"emailgo/internal/domain/campaign"
 	"emailgo/internal/endpoints"
+	"emailgo/internal/infrastructure/credential"
 	"emailgo/internal/infrastructure/database"
-	"emailgo/internal/infrastructure/mail"
+	"flag"
 	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/joho/godotenv"
 )
 
 func main() {
+	configFile := flag.String("config", "", "file with the settings, "+config.DefaultFile+" by default")
+	flag.Parse()
 
-	err := godotenv.Load("../../.env")
+	settings, err := config.Load(*configFile)
+	if err == nil {
+		err = settings.RequireAPI()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	roles, err := endpoints.ParseRoleMapping(settings.API.RoleMapping)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Fatal(err)
 	}
+	endpoints.Roles = roles
+
+	validator, err := credential.NewValidator(context.Background(), settings.API.Keycloak, settings.API.ClientID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	endpoints.ValidateToken = validator.ValidateToken
 
 	r := chi.NewRouter()
 
@@ -26,25 +46,64 @@ func main() {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	if settings.API.RequestTimeout > 0 {
+		r.Use(middleware.Timeout(settings.API.RequestTimeout))
+	}
 
-	db := database.NewDatabase()
+	db := database.NewDatabase(settings.Database)
 
 	campaignService := campaign.ServiceImp{
-		Repository: &database.CampaignRepository{Db: db},
-		SendMail:   mail.SendMail,
+		Repository:  &database.CampaignRepository{Db: db, Timeout: settings.Database.Timeout},
+		GracePeriod: settings.API.GracePeriod,
+	}
+
+	apiKeyService := apikey.ServiceImp{
+		Repository: &database.ApiKeyRepository{Db: db, Timeout: settings.Database.Timeout},
 	}
 
 	handler := endpoints.Handler{
 		CampaignService: &campaignService,
+		ApiKeyService:   &apiKeyService,
 	}
+	endpoints.ValidateApiKey = handler.ValidateApiKey
 
 	r.Route("/campaigns", func(r chi.Router) {
 		r.Use(endpoints.Auth)
-		r.Post("/", endpoints.HandlerError(handler.CampaignPost))
-		r.Get("/{id}", endpoints.HandlerError(handler.CampaignGetById))
-		r.Delete("/delete/{id}", endpoints.HandlerError(handler.CampaignDelete))
-		r.Patch("/start/{id}", endpoints.HandlerError(handler.CampaignStart))
+
+		r.Group(func(r chi.Router) {
+			r.Use(endpoints.Authorize(endpoints.PermissionRead))
+			r.Get("/", endpoints.HandlerError(handler.CampaignList))
+			r.Get("/{id}", endpoints.HandlerError(handler.CampaignGetById))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(endpoints.Authorize(endpoints.PermissionWrite))
+			r.Use(endpoints.IfMatch)
+			r.Post("/", endpoints.HandlerError(handler.CampaignPost))
+			r.Put("/{id}", endpoints.HandlerError(handler.CampaignReplace))
+			r.Patch("/{id}", endpoints.HandlerError(handler.CampaignUpdate))
+			r.Delete("/delete/{id}", endpoints.HandlerError(handler.CampaignDelete))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(endpoints.Authorize(endpoints.PermissionSend))
+			r.Use(endpoints.IfMatch)
+			r.Patch("/start/{id}", endpoints.HandlerError(handler.CampaignStart))
+			r.Patch("/schedule/{id}", endpoints.HandlerError(handler.CampaignSchedule))
+			r.Patch("/unschedule/{id}", endpoints.HandlerError(handler.CampaignUnschedule))
+			r.Patch("/cancel/{id}", endpoints.HandlerError(handler.CampaignCancel))
+			r.Patch("/pause/{id}", endpoints.HandlerError(handler.CampaignPause))
+			r.Patch("/resume/{id}", endpoints.HandlerError(handler.CampaignResume))
+			r.Patch("/undo/{id}", endpoints.HandlerError(handler.CampaignUndo))
+		})
+	})
+
+	r.Route("/apikeys", func(r chi.Router) {
+		r.Use(endpoints.Auth)
+		r.Post("/", endpoints.HandlerError(handler.ApiKeyPost))
+		r.Get("/", endpoints.HandlerError(handler.ApiKeyList))
+		r.Delete("/{id}", endpoints.HandlerError(handler.ApiKeyDelete))
 	})
 
-	http.ListenAndServe(":3000", r)
+	log.Fatal(http.ListenAndServe(settings.API.Address, r))
 }
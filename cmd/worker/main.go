@@ -1,42 +1,77 @@
 package main
 
 import (
+	"context"
+	"emailgo/internal/config"
 	"emailgo/internal/domain/campaign"
 	"emailgo/internal/infrastructure/database"
 	"emailgo/internal/infrastructure/mail"
+	"emailgo/internal/worker"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/rs/xid"
 )
 
 func main() {
-	err := godotenv.Load("../../.env")
+	configFile := flag.String("config", "", "file with the settings, "+config.DefaultFile+" by default")
+	flag.Parse()
+
+	settings, err := config.Load(*configFile)
+	if err == nil {
+		err = settings.RequireWorker()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mailer, err := mail.NewMailer(settings.Mail)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Fatal(err)
 	}
 
-	db := database.NewDatabase()
-	repository := database.CampaignRepository{Db: db}
+	db := database.NewDatabase(settings.Database)
 	campaignService := campaign.ServiceImp{
-		Repository: &repository,
-		SendMail:   mail.SendMail,
+		Repository:      &database.CampaignRepository{Db: db, Timeout: settings.Database.Timeout},
+		Mailer:          mailer,
+		RetryPolicy:     settings.Sending.RetryPolicy,
+		WorkerID:        workerID(),
+		LeaseDuration:   settings.Sending.LeaseDuration,
+		MessageIdDomain: messageIdDomain(settings.Mail.From),
+		SendTimeout:     settings.Sending.SendTimeout,
 	}
 
-	for {
-		campaigns, err := repository.GetCampaignsToBeSent()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-		if err != nil {
-			println(err.Error())
-		}
+	campaignWorker := worker.Worker{Sender: &campaignService, Config: settings.Worker}
+	if database.IsPostgres(db) {
+		go database.ListenCampaigns(ctx, settings.Database.DSN, func(notification database.CampaignNotification) {
+			// A started campaign is only claimed once its grace period is over.
+			time.AfterFunc(time.Until(notification.SendAt), campaignWorker.Wake)
+		})
+	}
 
-		println("Amount of campaigns: ", len(campaigns))
+	if err := campaignWorker.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
 
-		for _, campaign := range campaigns {
-			campaignService.SendEmailAndUpdateStatus(&campaign)
-			println("Campaign sent: ", campaign.ID)
-		}
+// workerID names this process in campaign leases. It must differ between
+// replicas, so it combines the host name, the pid and a random part.
+func workerID() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), xid.New().String())
+}
 
-		time.Sleep(10 * time.Second)
-	}
+// messageIdDomain takes the domain of the sender address for Message-IDs.
+func messageIdDomain(from string) string {
+	_, domain, _ := strings.Cut(strings.Trim(from, "<> "), "@")
+	return domain
 }
@@ -0,0 +1,153 @@
+// Package config reads the settings of the API and of the worker from the
+// environment and from an optional .env file, checking them at startup.
+package config
+
+import (
+	"emailgo/internal/domain/campaign"
+	"emailgo/internal/infrastructure/database"
+	"emailgo/internal/infrastructure/mail"
+	"emailgo/internal/worker"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+type Config struct {
+	API      API
+	Database database.Config
+	Mail     mail.Config
+	Sending  Sending
+	Worker   worker.Config
+}
+
+// API holds the settings only the API uses.
+type API struct {
+	// Address is where the API listens.
+	Address string
+	// RequestTimeout bounds each request. Zero means no bound.
+	RequestTimeout time.Duration
+	// GracePeriod is given to campaigns created without one.
+	GracePeriod time.Duration
+	// Keycloak is the URL of the realm that issues the tokens and ClientID
+	// the audience the tokens must have.
+	Keycloak string
+	ClientID string
+	// RoleMapping is read by endpoints.ParseRoleMapping.
+	RoleMapping string
+}
+
+// Sending holds how the worker sends each campaign.
+type Sending struct {
+	RetryPolicy   campaign.RetryPolicy
+	LeaseDuration time.Duration
+	// SendTimeout bounds each delivery. Zero means no bound.
+	SendTimeout time.Duration
+}
+
+// DefaultFile is read when no file is named, as long as it exists.
+const DefaultFile = ".env"
+
+// Load reads the settings from the environment and from file, a .env file
+// whose values the environment overrides. When file is empty CONFIG_FILE
+// names it, and without either DefaultFile is read if it exists. The error
+// lists every setting that is invalid.
+func Load(file string) (Config, error) {
+	values, err := readFile(file)
+	if err != nil {
+		return Config{}, err
+	}
+
+	r := &reader{values: values}
+	retryPolicy := campaign.DefaultRetryPolicy()
+	workerConfig := worker.DefaultConfig()
+
+	config := Config{
+		API: API{
+			Address:        r.string("API_ADDRESS", ":3000"),
+			RequestTimeout: r.duration("REQUEST_TIMEOUT", 0),
+			GracePeriod:    r.duration("GRACE_PERIOD", campaign.DefaultGracePeriod),
+			Keycloak:       r.string("KEYCLOAK", ""),
+			ClientID:       r.string("KEYCLOAK_CLIENT_ID", "emailn"),
+			RoleMapping:    r.string("ROLE_MAPPING", ""),
+		},
+		Database: database.Config{
+			Driver:  r.oneOf("DATABASE_DRIVER", database.DriverPostgres, database.DriverSQLite),
+			DSN:     r.required("DATABASE"),
+			Timeout: r.duration("DATABASE_TIMEOUT", 0),
+		},
+		Mail: mail.Config{
+			Transport: r.oneOf("MAIL_TRANSPORT", mail.TransportSMTP, mail.TransportFile, mail.TransportStdout, mail.TransportHTTP),
+			From:      r.string("EMAIL_FROM", r.string("EMAIL_USER", "")),
+			Host:      r.string("EMAIL_SMTP", ""),
+			Port:      r.positive("EMAIL_PORT", 587),
+			Username:  r.string("EMAIL_USER", ""),
+			Password:  r.string("EMAIL_PASSWORD", ""),
+			TLS:       r.oneOf("EMAIL_TLS", mail.TLSStartTLS, mail.TLSImplicit, mail.TLSNone),
+			Auth:      r.oneOf("EMAIL_AUTH", mail.AuthPlain, mail.AuthLogin, mail.AuthCRAMMD5, mail.AuthNone),
+			FileDir:   r.string("MAIL_FILE_DIR", "mails"),
+			HTTPURL:   r.string("MAIL_HTTP_URL", ""),
+			HTTPToken: r.string("MAIL_HTTP_TOKEN", ""),
+		},
+		Sending: Sending{
+			RetryPolicy: campaign.RetryPolicy{
+				MaxAttempts:    r.positive("MAIL_MAX_ATTEMPTS", retryPolicy.MaxAttempts),
+				InitialBackoff: r.duration("MAIL_RETRY_BACKOFF", retryPolicy.InitialBackoff),
+				MaxBackoff:     r.duration("MAIL_RETRY_MAX_BACKOFF", retryPolicy.MaxBackoff),
+			},
+			LeaseDuration: r.duration("WORKER_LEASE_DURATION", campaign.DefaultLeaseDuration),
+			SendTimeout:   r.duration("MAIL_SEND_TIMEOUT", 0),
+		},
+		Worker: worker.Config{
+			Concurrency:     r.positive("WORKER_CONCURRENCY", workerConfig.Concurrency),
+			QueueSize:       r.positive("WORKER_QUEUE_SIZE", workerConfig.QueueSize),
+			PollInterval:    r.duration("WORKER_POLL_INTERVAL", workerConfig.PollInterval),
+			ShutdownTimeout: r.duration("WORKER_SHUTDOWN_TIMEOUT", workerConfig.ShutdownTimeout),
+		},
+	}
+
+	return config, errors.Join(r.errs...)
+}
+
+// RequireAPI reports the settings the API cannot start without.
+func (c Config) RequireAPI() error {
+	if c.API.Keycloak == "" {
+		return errors.New("KEYCLOAK is required")
+	}
+	return nil
+}
+
+// RequireWorker reports the settings the selected mail transport cannot
+// send without.
+func (c Config) RequireWorker() error {
+	switch {
+	case c.Mail.Transport == mail.TransportSMTP && c.Mail.Host == "":
+		return errors.New("EMAIL_SMTP is required by the smtp transport")
+	case c.Mail.Transport == mail.TransportHTTP && c.Mail.HTTPURL == "":
+		return errors.New("MAIL_HTTP_URL is required by the http transport")
+	}
+	return nil
+}
+
+// readFile reads the values of the .env file to load. Only DefaultFile may
+// be missing.
+func readFile(file string) (map[string]string, error) {
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	optional := file == ""
+	if optional {
+		file = DefaultFile
+	}
+
+	values, err := godotenv.Read(file)
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, errors.New("config file " + file + " could not be read: " + err.Error())
+	}
+	return values, nil
+}
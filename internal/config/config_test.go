@@ -0,0 +1,149 @@
+package config
+
+import (
+	"emailgo/internal/domain/campaign"
+	"emailgo/internal/infrastructure/database"
+	"emailgo/internal/infrastructure/mail"
+	"emailgo/internal/worker"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupEnv unsets the settings a test does not set itself.
+func setupEnv(t *testing.T, values map[string]string) {
+	for _, name := range []string{
+		"CONFIG_FILE", "API_ADDRESS", "REQUEST_TIMEOUT", "GRACE_PERIOD", "KEYCLOAK", "KEYCLOAK_CLIENT_ID", "ROLE_MAPPING",
+		"DATABASE_DRIVER", "DATABASE", "DATABASE_TIMEOUT",
+		"MAIL_TRANSPORT", "EMAIL_FROM", "EMAIL_SMTP", "EMAIL_PORT", "EMAIL_USER", "EMAIL_PASSWORD", "EMAIL_TLS", "EMAIL_AUTH",
+		"MAIL_FILE_DIR", "MAIL_HTTP_URL", "MAIL_HTTP_TOKEN",
+		"MAIL_MAX_ATTEMPTS", "MAIL_RETRY_BACKOFF", "MAIL_RETRY_MAX_BACKOFF", "MAIL_SEND_TIMEOUT",
+		"WORKER_LEASE_DURATION", "WORKER_CONCURRENCY", "WORKER_QUEUE_SIZE", "WORKER_POLL_INTERVAL", "WORKER_SHUTDOWN_TIMEOUT",
+	} {
+		t.Setenv(name, values[name])
+		if _, found := values[name]; !found {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "settings.env")
+	require.Nil(t, os.WriteFile(file, []byte(content), 0o600))
+	return file
+}
+
+func Test_Load_OnlyDatabaseIsSet_UseDefaults(t *testing.T) {
+	setupEnv(t, map[string]string{"DATABASE": "postgres://localhost/emailgo"})
+
+	config, err := Load("")
+
+	require.Nil(t, err)
+	assert.Equal(t, ":3000", config.API.Address)
+	assert.Equal(t, "emailn", config.API.ClientID)
+	assert.Equal(t, campaign.DefaultGracePeriod, config.API.GracePeriod)
+	assert.Equal(t, database.Config{Driver: database.DriverPostgres, DSN: "postgres://localhost/emailgo"}, config.Database)
+	assert.Equal(t, mail.TransportSMTP, config.Mail.Transport)
+	assert.Equal(t, 587, config.Mail.Port)
+	assert.Equal(t, mail.TLSStartTLS, config.Mail.TLS)
+	assert.Equal(t, campaign.DefaultRetryPolicy(), config.Sending.RetryPolicy)
+	assert.Equal(t, campaign.DefaultLeaseDuration, config.Sending.LeaseDuration)
+	assert.Equal(t, worker.DefaultConfig(), config.Worker)
+}
+
+func Test_Load_SettingsAreSet_ReadThem(t *testing.T) {
+	setupEnv(t, map[string]string{
+		"DATABASE_DRIVER":    database.DriverSQLite,
+		"DATABASE":           "emailgo.db",
+		"DATABASE_TIMEOUT":   "5s",
+		"EMAIL_USER":         "ana@teste.com",
+		"EMAIL_PORT":         "465",
+		"MAIL_MAX_ATTEMPTS":  "3",
+		"WORKER_CONCURRENCY": "8",
+	})
+
+	config, err := Load("")
+
+	require.Nil(t, err)
+	assert.Equal(t, database.DriverSQLite, config.Database.Driver)
+	assert.Equal(t, 5*time.Second, config.Database.Timeout)
+	assert.Equal(t, "ana@teste.com", config.Mail.From)
+	assert.Equal(t, 465, config.Mail.Port)
+	assert.Equal(t, 3, config.Sending.RetryPolicy.MaxAttempts)
+	assert.Equal(t, 8, config.Worker.Concurrency)
+}
+
+func Test_Load_SettingsAreInvalid_ReportEveryOne(t *testing.T) {
+	setupEnv(t, map[string]string{
+		"DATABASE_DRIVER":      "mysql",
+		"EMAIL_PORT":           "smtp",
+		"WORKER_POLL_INTERVAL": "-1s",
+	})
+
+	_, err := Load("")
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), `DATABASE_DRIVER is invalid: "mysql" is not one of postgres, sqlite`)
+	assert.Contains(t, err.Error(), "DATABASE is required")
+	assert.Contains(t, err.Error(), `EMAIL_PORT is invalid: "smtp" is not a positive number`)
+	assert.Contains(t, err.Error(), `WORKER_POLL_INTERVAL is invalid: "-1s" is not a duration such as 30s`)
+}
+
+func Test_Load_FileIsGiven_EnvironmentOverridesIt(t *testing.T) {
+	setupEnv(t, map[string]string{"API_ADDRESS": ":8080"})
+	file := writeFile(t, "DATABASE=emailgo.db\nDATABASE_DRIVER=sqlite\nAPI_ADDRESS=:4000\n")
+
+	config, err := Load(file)
+
+	require.Nil(t, err)
+	assert.Equal(t, "emailgo.db", config.Database.DSN)
+	assert.Equal(t, database.DriverSQLite, config.Database.Driver)
+	assert.Equal(t, ":8080", config.API.Address)
+}
+
+func Test_Load_EnvironmentIsSetToEmpty_ClearTheFileValue(t *testing.T) {
+	setupEnv(t, map[string]string{"EMAIL_PASSWORD": "", "MAIL_HTTP_TOKEN": ""})
+	file := writeFile(t, "DATABASE=emailgo.db\nEMAIL_PASSWORD=secret\nMAIL_HTTP_TOKEN=token\n")
+
+	config, err := Load(file)
+
+	require.Nil(t, err)
+	assert.Empty(t, config.Mail.Password)
+	assert.Empty(t, config.Mail.HTTPToken)
+}
+
+func Test_Load_ConfigFileNamesTheFile(t *testing.T) {
+	setupEnv(t, map[string]string{"CONFIG_FILE": writeFile(t, "DATABASE=emailgo.db\n")})
+
+	config, err := Load("")
+
+	require.Nil(t, err)
+	assert.Equal(t, "emailgo.db", config.Database.DSN)
+}
+
+func Test_Load_FileIsMissing_Err(t *testing.T) {
+	setupEnv(t, map[string]string{"DATABASE": "emailgo.db"})
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.env"))
+
+	assert.ErrorContains(t, err, "missing.env could not be read")
+}
+
+func Test_RequireAPI_KeycloakIsMissing_Err(t *testing.T) {
+	assert.EqualError(t, Config{}.RequireAPI(), "KEYCLOAK is required")
+	assert.Nil(t, Config{API: API{Keycloak: "http://localhost:8080/realms/provider"}}.RequireAPI())
+}
+
+func Test_RequireWorker_TransportIsNotSetUp_Err(t *testing.T) {
+	smtpConfig := Config{Mail: mail.Config{Transport: mail.TransportSMTP}}
+	httpConfig := Config{Mail: mail.Config{Transport: mail.TransportHTTP}}
+	stdoutConfig := Config{Mail: mail.Config{Transport: mail.TransportStdout}}
+
+	assert.EqualError(t, smtpConfig.RequireWorker(), "EMAIL_SMTP is required by the smtp transport")
+	assert.EqualError(t, httpConfig.RequireWorker(), "MAIL_HTTP_URL is required by the http transport")
+	assert.Nil(t, stdoutConfig.RequireWorker())
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reader looks settings up in the environment first and then in the values
+// of the file, collecting an error for each invalid one. A variable of the
+// environment wins over the file even when empty, so it can clear a value
+// the file sets; an empty setting is then taken as not set.
+type reader struct {
+	values map[string]string
+	errs   []error
+}
+
+func (r *reader) lookup(name string) string {
+	if value, found := os.LookupEnv(name); found {
+		return value
+	}
+	return r.values[name]
+}
+
+func (r *reader) invalid(name string, value string, reason string) {
+	r.errs = append(r.errs, fmt.Errorf("%s is invalid: %q %s", name, value, reason))
+}
+
+func (r *reader) string(name string, fallback string) string {
+	if value := r.lookup(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (r *reader) required(name string) string {
+	value := r.lookup(name)
+	if value == "" {
+		r.errs = append(r.errs, fmt.Errorf("%s is required", name))
+	}
+	return value
+}
+
+// oneOf reads one of values, the first being the default.
+func (r *reader) oneOf(name string, values ...string) string {
+	value := r.lookup(name)
+	if value == "" {
+		return values[0]
+	}
+	if !slices.Contains(values, value) {
+		r.invalid(name, value, "is not one of "+strings.Join(values, ", "))
+	}
+	return value
+}
+
+func (r *reader) positive(name string, fallback int) int {
+	value := r.lookup(name)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		r.invalid(name, value, "is not a positive number")
+		return fallback
+	}
+	return number
+}
+
+// duration reads a duration such as 30s.
+func (r *reader) duration(name string, fallback time.Duration) time.Duration {
+	value := r.lookup(name)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		r.invalid(name, value, "is not a duration such as 30s")
+		return fallback
+	}
+	return duration
+}
@@ -0,0 +1,19 @@
+package contract
+
+import "time"
+
+type ApiKeyResponse struct {
+	ID         string
+	Name       string
+	Scopes     []string
+	Owner      string
+	CreatedOn  time.Time
+	LastUsedOn *time.Time
+	RevokedOn  *time.Time
+}
+
+// ApiKeyCreatedResponse carries the only copy of the key the API returns.
+type ApiKeyCreatedResponse struct {
+	ApiKeyResponse
+	Key string
+}
@@ -0,0 +1,6 @@
+package contract
+
+type CampaignListResponse struct {
+	Campaigns  []CampaignResponse
+	NextCursor string
+}
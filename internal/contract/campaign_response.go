@@ -1,10 +1,21 @@
 package contract
 
+import "time"
+
 type CampaignResponse struct {
 	ID                   string
 	Name                 string
+	Subject              string
 	Content              string
 	Status               string
 	AmountOfEmailsToSend int
 	CreatedBy            string
+	ScheduledFor         *time.Time
+	// GracePeriod is how many seconds the campaign waits once started.
+	GracePeriod int
+	// SendingAt is when the campaign is sent, set once it is started or
+	// scheduled.
+	SendingAt *time.Time
+	// Version changes on every saved change and is sent as the ETag.
+	Version int
 }
@@ -0,0 +1,15 @@
+package contract
+
+import "time"
+
+type ListCampaignsRequest struct {
+	Status      string
+	Name        string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        string
+	Order       string
+	Cursor      string
+	Limit       int
+	CreatedBy   string
+}
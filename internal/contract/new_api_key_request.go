@@ -0,0 +1,7 @@
+package contract
+
+type NewApiKeyRequest struct {
+	Name   string
+	Scopes []string
+	Owner  string
+}
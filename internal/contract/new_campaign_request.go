@@ -1,8 +1,23 @@
 package contract
 
+import "time"
+
 type NewCampaignRequest struct {
-	Name      string
-	Content   string
-	Emails    []string
-	CreatedBy string
+	Name         string
+	Subject      string
+	Content      string
+	Emails       []string
+	Contacts     []ContactRequest
+	ScheduledFor *time.Time
+	// GracePeriod is how many seconds the campaign waits once started,
+	// during which it can be undone. It defaults to the server setting.
+	GracePeriod *int
+	CreatedBy   string
+}
+
+type ContactRequest struct {
+	Email     string
+	FirstName string
+	LastName  string
+	Fields    map[string]string
 }
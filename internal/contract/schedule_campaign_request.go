@@ -0,0 +1,7 @@
+package contract
+
+import "time"
+
+type ScheduleCampaignRequest struct {
+	ScheduledFor time.Time
+}
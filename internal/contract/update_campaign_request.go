@@ -0,0 +1,13 @@
+package contract
+
+// UpdateCampaignRequest changes only the fields it carries: a nil field keeps
+// the saved value, while Emails or Contacts, when present, replace every
+// contact of the campaign.
+type UpdateCampaignRequest struct {
+	Name        *string
+	Subject     *string
+	Content     *string
+	GracePeriod *int
+	Emails      []string
+	Contacts    []ContactRequest
+}
@@ -0,0 +1,96 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	internalerrors "emailgo/internal/internal-errors"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeSend  = "send"
+
+	keyPrefix = "egk"
+)
+
+var ErrInvalidKey = errors.New("invalid api key")
+
+// ApiKey lets a service act on behalf of its owner without a token. Only a
+// hash of the secret is stored; the key itself is shown once, on creation.
+type ApiKey struct {
+	ID         string    `validate:"required" gorm:"size:50;not null"`
+	Name       string    `validate:"min=3,max=50" gorm:"size:50;not null"`
+	Hash       string    `gorm:"size:64;not null"`
+	Scopes     []string  `validate:"min=1,dive,oneof=read write send" gorm:"serializer:json"`
+	Owner      string    `validate:"email" gorm:"size:50;not null"`
+	CreatedOn  time.Time `gorm:"not null"`
+	LastUsedOn *time.Time
+	RevokedOn  *time.Time
+}
+
+func (a *ApiKey) Revoked() bool {
+	return a.RevokedOn != nil
+}
+
+func (a *ApiKey) Revoke() {
+	now := time.Now()
+	a.RevokedOn = &now
+}
+
+func (a *ApiKey) Used() {
+	now := time.Now()
+	a.LastUsedOn = &now
+}
+
+// Matches tells whether the secret is the one the key was created with.
+func (a *ApiKey) Matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(a.Hash)) == 1
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseKey splits a key in the form egk_<id>_<secret>.
+func ParseKey(key string) (string, string, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", ErrInvalidKey
+	}
+	return parts[1], parts[2], nil
+}
+
+// NewApiKey returns the key to be saved and the plain key to hand to the
+// owner.
+func NewApiKey(name string, scopes []string, owner string) (*ApiKey, string, error) {
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, "", internalerrors.ErrInternal
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	apiKey := &ApiKey{
+		ID:        xid.New().String(),
+		Name:      name,
+		Hash:      hash(secret),
+		Scopes:    scopes,
+		Owner:     owner,
+		CreatedOn: time.Now(),
+	}
+	err := internalerrors.ValidateStruct(apiKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return apiKey, keyPrefix + "_" + apiKey.ID + "_" + secret, nil
+}
@@ -0,0 +1,75 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	name   = "batch jobs"
+	scopes = []string{ScopeWrite, ScopeSend}
+	owner  = "teste@teste.com.br"
+)
+
+func Test_NewApiKey_CreateApiKey(t *testing.T) {
+	apiKey, key, err := NewApiKey(name, scopes, owner)
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, apiKey.ID)
+	assert.Equal(t, name, apiKey.Name)
+	assert.Equal(t, scopes, apiKey.Scopes)
+	assert.Equal(t, owner, apiKey.Owner)
+	assert.True(t, strings.HasPrefix(key, "egk_"+apiKey.ID+"_"))
+}
+
+func Test_NewApiKey_HashIsNotTheSecret(t *testing.T) {
+	apiKey, key, _ := NewApiKey(name, scopes, owner)
+
+	_, secret, _ := ParseKey(key)
+
+	assert.NotContains(t, key, apiKey.Hash)
+	assert.True(t, apiKey.Matches(secret))
+	assert.False(t, apiKey.Matches(secret+"x"))
+}
+
+func Test_NewApiKey_MustValidateName(t *testing.T) {
+	_, _, err := NewApiKey("", scopes, owner)
+
+	assert.Equal(t, "name is required with min 3", err.Error())
+}
+
+func Test_NewApiKey_MustValidateScopesMin(t *testing.T) {
+	_, _, err := NewApiKey(name, nil, owner)
+
+	assert.Equal(t, "scopes is required with min 1", err.Error())
+}
+
+func Test_NewApiKey_MustValidateScopes(t *testing.T) {
+	_, _, err := NewApiKey(name, []string{"admin"}, owner)
+
+	assert.Equal(t, "scopes[0] must be one of read write send", err.Error())
+}
+
+func Test_NewApiKey_MustValidateOwner(t *testing.T) {
+	_, _, err := NewApiKey(name, scopes, "")
+
+	assert.Equal(t, "owner is invalid", err.Error())
+}
+
+func Test_ParseKey_InvalidKey_ErrInvalidKey(t *testing.T) {
+	for _, key := range []string{"", "egk_", "egk_id", "egk__secret", "abc_id_secret"} {
+		_, _, err := ParseKey(key)
+
+		assert.Equal(t, ErrInvalidKey, err, key)
+	}
+}
+
+func Test_Revoke_SetRevokedOn(t *testing.T) {
+	apiKey, _, _ := NewApiKey(name, scopes, owner)
+
+	apiKey.Revoke()
+
+	assert.True(t, apiKey.Revoked())
+}
@@ -0,0 +1,13 @@
+package apikey
+
+import "context"
+
+type Repository interface {
+	Create(ctx context.Context, apiKey *ApiKey) error
+	Update(ctx context.Context, apiKey *ApiKey) error
+	// UpdateLastUsed saves only LastUsedOn, as long as the key is not
+	// revoked, and returns ErrInvalidKey otherwise.
+	UpdateLastUsed(ctx context.Context, apiKey *ApiKey) error
+	GetBy(ctx context.Context, id string) (*ApiKey, error)
+	GetByOwner(ctx context.Context, owner string) ([]ApiKey, error)
+}
@@ -0,0 +1,118 @@
+package apikey
+
+import (
+	"context"
+	"emailgo/internal/contract"
+	internalerrors "emailgo/internal/internal-errors"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Create(ctx context.Context, request contract.NewApiKeyRequest) (*contract.ApiKeyCreatedResponse, error)
+	List(ctx context.Context, owner string) ([]contract.ApiKeyResponse, error)
+	Revoke(ctx context.Context, id string, owner string) error
+	Authenticate(ctx context.Context, key string) (*contract.ApiKeyResponse, error)
+}
+
+type ServiceImp struct {
+	Repository Repository
+}
+
+func newApiKeyResponse(apiKey *ApiKey) contract.ApiKeyResponse {
+	return contract.ApiKeyResponse{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Scopes:     apiKey.Scopes,
+		Owner:      apiKey.Owner,
+		CreatedOn:  apiKey.CreatedOn,
+		LastUsedOn: apiKey.LastUsedOn,
+		RevokedOn:  apiKey.RevokedOn,
+	}
+}
+
+func (s *ServiceImp) Create(ctx context.Context, request contract.NewApiKeyRequest) (*contract.ApiKeyCreatedResponse, error) {
+	apiKey, key, err := NewApiKey(request.Name, request.Scopes, request.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Repository.Create(ctx, apiKey)
+	if err != nil {
+		return nil, internalerrors.ErrInternal
+	}
+
+	return &contract.ApiKeyCreatedResponse{ApiKeyResponse: newApiKeyResponse(apiKey), Key: key}, nil
+}
+
+func (s *ServiceImp) List(ctx context.Context, owner string) ([]contract.ApiKeyResponse, error) {
+	apiKeys, err := s.Repository.GetByOwner(ctx, owner)
+	if err != nil {
+		return nil, internalerrors.ErrInternal
+	}
+
+	response := make([]contract.ApiKeyResponse, len(apiKeys))
+	for index := range apiKeys {
+		response[index] = newApiKeyResponse(&apiKeys[index])
+	}
+	return response, nil
+}
+
+func (s *ServiceImp) Revoke(ctx context.Context, id string, owner string) error {
+	apiKey, err := s.Repository.GetBy(ctx, id)
+	if err != nil {
+		return internalerrors.ProcessErrorToReturn(err)
+	}
+
+	if apiKey.Owner != owner {
+		return internalerrors.ErrForbidden
+	}
+
+	if apiKey.Revoked() {
+		return nil
+	}
+
+	apiKey.Revoke()
+	err = s.Repository.Update(ctx, apiKey)
+	if err != nil {
+		return internalerrors.ErrInternal
+	}
+
+	return nil
+}
+
+// Authenticate returns the key matching the plain key, unless it was
+// revoked. Every failure is reported as ErrInvalidKey.
+func (s *ServiceImp) Authenticate(ctx context.Context, key string) (*contract.ApiKeyResponse, error) {
+	id, secret, err := ParseKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := s.Repository.GetBy(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, internalerrors.ErrInternal
+	}
+
+	if apiKey.Revoked() || !apiKey.Matches(secret) {
+		return nil, ErrInvalidKey
+	}
+
+	// Only the use is saved, so a key revoked meanwhile stays revoked and
+	// is refused.
+	apiKey.Used()
+	err = s.Repository.UpdateLastUsed(ctx, apiKey)
+	if errors.Is(err, ErrInvalidKey) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, internalerrors.ErrInternal
+	}
+
+	response := newApiKeyResponse(apiKey)
+	return &response, nil
+}
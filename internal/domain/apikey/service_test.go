@@ -0,0 +1,183 @@
+package apikey_test
+
+import (
+	"context"
+	"emailgo/internal/contract"
+	"emailgo/internal/domain/apikey"
+	internalerrors "emailgo/internal/internal-errors"
+	internalmock "emailgo/internal/test/internalmock"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+var (
+	newApiKey = contract.NewApiKeyRequest{
+		Name:   "batch jobs",
+		Scopes: []string{apikey.ScopeWrite},
+		Owner:  "teste@test.com.br",
+	}
+	savedApiKey    *apikey.ApiKey
+	savedKey       string
+	repositoryMock *internalmock.ApiKeyRepositoryMock
+	service        = apikey.ServiceImp{}
+)
+
+// requestKey marks the context of the request, so the mocks can check that
+// it is the one the repository receives.
+type requestKey struct{}
+
+var requestCtx = context.WithValue(context.Background(), requestKey{}, "request")
+
+// fromRequest matches requestCtx and the contexts derived from it.
+var fromRequest = mock.MatchedBy(func(ctx context.Context) bool {
+	return ctx.Value(requestKey{}) == "request"
+})
+
+func setupServiceTest() {
+	repositoryMock = new(internalmock.ApiKeyRepositoryMock)
+	service.Repository = repositoryMock
+	savedApiKey, savedKey, _ = apikey.NewApiKey(newApiKey.Name, newApiKey.Scopes, newApiKey.Owner)
+}
+
+func Test_Create_RequestIsValid_ReturnKeyOnce(t *testing.T) {
+	setupServiceTest()
+	var apiKeySaved *apikey.ApiKey
+	repositoryMock.On("Create", fromRequest, mock.MatchedBy(func(apiKey *apikey.ApiKey) bool {
+		apiKeySaved = apiKey
+		return apiKey.Owner == newApiKey.Owner && apiKey.Name == newApiKey.Name
+	})).Return(nil)
+
+	response, err := service.Create(requestCtx, newApiKey)
+
+	assert.Nil(t, err)
+	assert.Equal(t, apiKeySaved.ID, response.ID)
+	assert.NotEmpty(t, response.Key)
+	assert.NotContains(t, response.Key, apiKeySaved.Hash)
+}
+
+func Test_Create_RequestIsNotValid_Err(t *testing.T) {
+	setupServiceTest()
+
+	_, err := service.Create(requestCtx, contract.NewApiKeyRequest{})
+
+	assert.NotNil(t, err)
+	repositoryMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func Test_Create_ErrorOnRepository_ErrInternal(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("Create", fromRequest, mock.Anything).Return(errors.New("error to save on database"))
+
+	_, err := service.Create(requestCtx, newApiKey)
+
+	assert.True(t, errors.Is(err, internalerrors.ErrInternal))
+}
+
+func Test_List_ReturnKeysOfOwner(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetByOwner", fromRequest, newApiKey.Owner).Return([]apikey.ApiKey{*savedApiKey}, nil)
+
+	response, err := service.List(requestCtx, newApiKey.Owner)
+
+	assert.Nil(t, err)
+	assert.Len(t, response, 1)
+	assert.Equal(t, savedApiKey.ID, response[0].ID)
+}
+
+func Test_Revoke_KeyWasRevoked_Nil(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, savedApiKey.ID).Return(savedApiKey, nil)
+	repositoryMock.On("Update", fromRequest, mock.MatchedBy(func(apiKey *apikey.ApiKey) bool {
+		return apiKey.Revoked()
+	})).Return(nil)
+
+	err := service.Revoke(requestCtx, savedApiKey.ID, newApiKey.Owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Revoke_CallerIsNotOwner_ErrForbidden(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, savedApiKey.ID).Return(savedApiKey, nil)
+
+	err := service.Revoke(requestCtx, savedApiKey.ID, "other@test.com")
+
+	assert.True(t, errors.Is(err, internalerrors.ErrForbidden))
+	repositoryMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func Test_Revoke_KeyWasNotFound_ErrRecordNotFound(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+
+	err := service.Revoke(requestCtx, "invalid", newApiKey.Owner)
+
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func Test_Authenticate_KeyIsValid_ReturnOwnerAndScopes(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, savedApiKey.ID).Return(savedApiKey, nil)
+	repositoryMock.On("UpdateLastUsed", fromRequest, mock.Anything).Return(nil)
+
+	response, err := service.Authenticate(requestCtx, savedKey)
+
+	assert.Nil(t, err)
+	assert.Equal(t, newApiKey.Owner, response.Owner)
+	assert.Equal(t, newApiKey.Scopes, response.Scopes)
+	assert.NotNil(t, response.LastUsedOn)
+}
+
+func Test_Authenticate_SecretIsWrong_ErrInvalidKey(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, savedApiKey.ID).Return(savedApiKey, nil)
+
+	_, err := service.Authenticate(requestCtx, "egk_"+savedApiKey.ID+"_wrong")
+
+	assert.Equal(t, apikey.ErrInvalidKey, err)
+}
+
+func Test_Authenticate_KeyWasRevoked_ErrInvalidKey(t *testing.T) {
+	setupServiceTest()
+	savedApiKey.Revoke()
+	repositoryMock.On("GetBy", fromRequest, savedApiKey.ID).Return(savedApiKey, nil)
+
+	_, err := service.Authenticate(requestCtx, savedKey)
+
+	assert.Equal(t, apikey.ErrInvalidKey, err)
+}
+
+func Test_Authenticate_KeyIsRevokedBeforeItIsMarkedUsed_ErrInvalidKey(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, savedApiKey.ID).Return(savedApiKey, nil)
+	repositoryMock.On("UpdateLastUsed", fromRequest, mock.Anything).Return(apikey.ErrInvalidKey)
+
+	_, err := service.Authenticate(requestCtx, savedKey)
+
+	assert.Equal(t, apikey.ErrInvalidKey, err)
+	repositoryMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func Test_Authenticate_MarkUsedFails_ErrInternal(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, savedApiKey.ID).Return(savedApiKey, nil)
+	repositoryMock.On("UpdateLastUsed", fromRequest, mock.Anything).Return(errors.New("connection refused"))
+
+	_, err := service.Authenticate(requestCtx, savedKey)
+
+	assert.Equal(t, internalerrors.ErrInternal, err)
+}
+
+func Test_Authenticate_KeyWasNotFound_ErrInvalidKey(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := service.Authenticate(requestCtx, savedKey)
+
+	assert.Equal(t, apikey.ErrInvalidKey, err)
+}
@@ -0,0 +1,12 @@
+package campaign
+
+// Caller is the authenticated user on whose behalf the service runs.
+// Admins may see and change campaigns created by anyone.
+type Caller struct {
+	Email string
+	Admin bool
+}
+
+func (c Caller) Owns(campaign *Campaign) bool {
+	return c.Admin || campaign.CreatedBy == c.Email
+}
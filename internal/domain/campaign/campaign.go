@@ -2,80 +2,352 @@ package campaign
 
 import (
 	internalerrors "emailgo/internal/internal-errors"
+	"strconv"
 	"time"
 
 	"github.com/rs/xid"
 )
 
 const (
-	Pending  = "Pending"
-	Started  = "Started"
-	Done     = "Done"
-	Canceled = "Canceled"
-	Deleted  = "Deleted"
-	Fail     = "Fail"
+	Pending   = "Pending"
+	Scheduled = "Scheduled"
+	Started   = "Started"
+	Sending   = "Sending"
+	Paused    = "Paused"
+	Done      = "Done"
+	Canceled  = "Canceled"
+	Deleted   = "Deleted"
+	Fail      = "Fail"
+)
+
+// MaxGracePeriod is the longest grace period, in seconds, a campaign may have.
+const MaxGracePeriod = 24 * 60 * 60
+
+const (
+	ContactQueued   = "Queued"
+	ContactDeferred = "Deferred"
+	ContactSent     = "Sent"
+	ContactFailed   = "Failed"
 )
 
 type Contact struct {
-	ID         string `gorm:"size:50"`
-	Email      string `validate:"email" gorm:"size:100"`
-	CampaignId string `gorm:"size:50"`
+	ID               string            `gorm:"size:50"`
+	Email            string            `validate:"email" gorm:"size:100"`
+	FirstName        string            `gorm:"size:100"`
+	LastName         string            `gorm:"size:100"`
+	Fields           map[string]string `gorm:"serializer:json"`
+	CampaignId       string            `gorm:"size:50"`
+	Status           string            `gorm:"size:20"`
+	UpdatedOn        time.Time
+	Error            string `gorm:"size:1024"`
+	Attempts         int
+	NextAttemptOn    *time.Time
+	MessageId        string            `gorm:"size:255"`
+	DeliveryAttempts []DeliveryAttempt `gorm:"foreignKey:ContactId"`
 }
 
-type Campaign struct {
-	ID        string    `validate:"required" gorm:"size:50;not null"`
-	Name      string    `validate:"min=5,max=24" gorm:"size:100;not null"`
-	CreatedOn time.Time `validate:"required" gorm:"not null"`
-	UpdatedOn time.Time
-	Content   string    `validate:"min=5,max=1024" gorm:"size:1024; not null"`
-	Contacts  []Contact `validate:"min=1,dive"`
-	Status    string    `gorm:"size:20;not null"`
-	CreatedBy string    `validate:"email" gorm:"size:50;not null"`
-}
-
-func (c *Campaign) Done() {
-	c.Status = Done
+func (c *Contact) Sent() {
+	c.Status = ContactSent
+	c.Error = ""
+	c.NextAttemptOn = nil
 	c.UpdatedOn = time.Now()
 }
 
-func (c *Campaign) Cancel() {
-	c.Status = Canceled
+func (c *Contact) Failed(err error) {
+	c.Status = ContactFailed
+	c.Error = err.Error()
+	c.NextAttemptOn = nil
 	c.UpdatedOn = time.Now()
 }
 
-func (c *Campaign) Delete() {
-	c.Status = Deleted
+// Deferred keeps the contact waiting for another attempt after a temporary
+// failure.
+func (c *Contact) Deferred(err error, nextAttemptOn time.Time) {
+	c.Status = ContactDeferred
+	c.Error = err.Error()
+	c.NextAttemptOn = &nextAttemptOn
 	c.UpdatedOn = time.Now()
 }
 
-func (c *Campaign) Fail() {
-	c.Status = Fail
-	c.UpdatedOn = time.Now()
+// Pending reports whether the contact still has to be sent to.
+func (c *Contact) Pending() bool {
+	return c.Status == ContactQueued || c.Status == ContactDeferred
+}
+
+// recordAttempt counts an attempt and adds it to the contact history.
+func (c *Contact) recordAttempt(err error, now time.Time) {
+	c.Attempts++
+	attempt := DeliveryAttempt{
+		CampaignId:  c.CampaignId,
+		ContactId:   c.ID,
+		Attempt:     c.Attempts,
+		AttemptedOn: now,
+	}
+	if err != nil {
+		attempt.Code = deliveryCode(err)
+		attempt.Temporary = IsTemporary(err)
+		attempt.Error = err.Error()
+	}
+	c.DeliveryAttempts = append(c.DeliveryAttempts, attempt)
 }
 
-func (c *Campaign) Started() {
-	c.Status = Started
+type Campaign struct {
+	ID           string    `validate:"required" gorm:"size:50;not null"`
+	Name         string    `validate:"min=5,max=24" gorm:"size:100;not null"`
+	Subject      string    `validate:"max=255" gorm:"size:255"`
+	CreatedOn    time.Time `validate:"required" gorm:"not null"`
+	UpdatedOn    time.Time
+	Content      string    `validate:"min=5,max=1024" gorm:"size:1024; not null"`
+	Contacts     []Contact `validate:"min=1,dive"`
+	Status       string    `gorm:"size:20;not null"`
+	CreatedBy    string    `validate:"email" gorm:"size:50;not null"`
+	ScheduledFor *time.Time
+	// GracePeriod is how many seconds a started campaign waits before it is
+	// sent, during which it can still be undone.
+	GracePeriod int
+	// SendAt is when a worker may start sending the campaign.
+	SendAt *time.Time
+	// LeaseOwner is the worker sending the campaign, which it may do until
+	// LeaseExpiresOn unless it renews the lease.
+	LeaseOwner     string `gorm:"size:100"`
+	LeaseExpiresOn *time.Time
+	// Version counts the saved changes of the campaign. Repositories only
+	// save a campaign still at the version it was read with and then
+	// increment it, returning internalerrors.ErrConflict otherwise. Renewing
+	// a lease is not counted, since clients do not see it.
+	Version int `gorm:"not null;default:0"`
+}
+
+// changeStatus moves the campaign to another status. Any lease is dropped,
+// so a worker renewing it learns that the campaign was changed under it.
+func (c *Campaign) changeStatus(to string) error {
+	if err := c.checkStatusChange(to); err != nil {
+		return err
+	}
+	c.Status = to
+	c.LeaseOwner = ""
+	c.LeaseExpiresOn = nil
 	c.UpdatedOn = time.Now()
+	return nil
+}
+
+// Claim leases the campaign to a worker until the given time. A campaign
+// whose lease expired may be claimed again.
+func (c *Campaign) Claim(owner string, leaseUntil time.Time) error {
+	if c.Status != Sending {
+		if err := c.changeStatus(Sending); err != nil {
+			return err
+		}
+	}
+	c.LeaseOwner = owner
+	c.LeaseExpiresOn = &leaseUntil
+	return nil
+}
+
+// Release ends the lease once the worker stops sending: the campaign is
+// completed when no contact is left waiting, and goes back to Started
+// otherwise, due again when the first of the rest is, so a later run sends
+// to them.
+func (c *Campaign) Release() error {
+	if !c.HasPendingContacts() {
+		return c.CompleteFromContacts()
+	}
+	c.SendAt = c.nextAttemptOn()
+	if c.Status == Started {
+		return nil
+	}
+	return c.changeStatus(Started)
+}
+
+// nextAttemptOn is when the first pending contact is due, a contact without
+// a next attempt being due right away.
+func (c *Campaign) nextAttemptOn() *time.Time {
+	var next *time.Time
+	for _, contact := range c.Contacts {
+		if !contact.Pending() {
+			continue
+		}
+		if contact.NextAttemptOn == nil {
+			now := time.Now()
+			return &now
+		}
+		if next == nil || contact.NextAttemptOn.Before(*next) {
+			next = contact.NextAttemptOn
+		}
+	}
+	return next
 }
 
-func NewCampaign(name string, content string, emails []string, createdBy string) (*Campaign, error) {
+func (c *Campaign) Done() error {
+	return c.changeStatus(Done)
+}
+
+func (c *Campaign) Cancel() error {
+	return c.changeStatus(Canceled)
+}
+
+func (c *Campaign) Delete() error {
+	return c.changeStatus(Deleted)
+}
 
-	contacts := make([]Contact, len(emails))
-	for index, email := range emails {
-		contacts[index].Email = email
-		contacts[index].ID = xid.New().String()
+func (c *Campaign) Fail() error {
+	return c.changeStatus(Fail)
+}
+
+// Started makes the campaign be sent once its grace period is over.
+func (c *Campaign) Started() error {
+	if err := c.changeStatus(Started); err != nil {
+		return err
 	}
+	sendAt := c.UpdatedOn.Add(time.Duration(c.GracePeriod) * time.Second)
+	c.SendAt = &sendAt
+	return nil
+}
+
+// Undo takes a started campaign back to Pending, as long as no message was
+// handed to the mail server yet.
+func (c *Campaign) Undo() error {
+	if c.Status != Started {
+		return &StatusTransitionError{From: c.Status, To: Pending}
+	}
+	for _, contact := range c.Contacts {
+		if contact.Attempts > 0 || contact.Status != ContactQueued {
+			return ErrAlreadyDispatched
+		}
+	}
+	c.SendAt = nil
+	return c.changeStatus(Pending)
+}
+
+// SetGracePeriod sets how many seconds the campaign waits once started.
+func (c *Campaign) SetGracePeriod(seconds int) error {
+	if seconds < 0 || seconds > MaxGracePeriod {
+		limit := strconv.Itoa(MaxGracePeriod)
+		return internalerrors.NewFieldError("graceperiod", "range", limit, "graceperiod must be between 0 and "+limit)
+	}
+	c.GracePeriod = seconds
+	return nil
+}
+
+func (c *Campaign) Pause() error {
+	return c.changeStatus(Paused)
+}
+
+// Resume puts a paused campaign back where it was: scheduled when its send
+// time is still ahead, started otherwise.
+func (c *Campaign) Resume() error {
+	if c.Status != Paused {
+		return &StatusTransitionError{From: c.Status, To: Started}
+	}
+	if c.ScheduledFor != nil && c.ScheduledFor.After(time.Now()) {
+		return c.changeStatus(Scheduled)
+	}
+	return c.changeStatus(Started)
+}
+
+// Schedule makes the campaign be sent once the given time has passed.
+func (c *Campaign) Schedule(scheduledFor time.Time) error {
+	if err := c.checkStatusChange(Scheduled); err != nil {
+		return err
+	}
+	if !scheduledFor.After(time.Now()) {
+		return internalerrors.NewFieldError("scheduledfor", "future", "", "scheduledfor must be in the future")
+	}
+	c.ScheduledFor = &scheduledFor
+	c.SendAt = &scheduledFor
+	return c.changeStatus(Scheduled)
+}
+
+func (c *Campaign) Unschedule() error {
+	if c.Status != Scheduled {
+		return &StatusTransitionError{From: c.Status, To: Pending}
+	}
+	c.ScheduledFor = nil
+	c.SendAt = nil
+	return c.changeStatus(Pending)
+}
+
+// CompleteFromContacts sets the campaign status from the delivery result of
+// each contact: the campaign fails only when no contact received the email.
+// Nothing changes while a contact is still waiting to be sent.
+func (c *Campaign) CompleteFromContacts() error {
+	if c.HasPendingContacts() {
+		return nil
+	}
+	for _, contact := range c.Contacts {
+		if contact.Status == ContactSent {
+			return c.Done()
+		}
+	}
+	return c.Fail()
+}
+
+func (c *Campaign) HasPendingContacts() bool {
+	for _, contact := range c.Contacts {
+		if contact.Pending() {
+			return true
+		}
+	}
+	return false
+}
+
+// Update replaces the name, subject, content and contacts of a pending
+// campaign. The campaign is left untouched when the new values are invalid.
+func (c *Campaign) Update(name string, subject string, content string, contacts []Contact) error {
+	if c.Status != Pending {
+		return ErrNotPending
+	}
+
+	now := time.Now()
+	updated := *c
+	updated.Name = name
+	updated.Subject = subject
+	updated.Content = content
+	updated.Contacts = queueContacts(append([]Contact(nil), contacts...), now)
+	updated.UpdatedOn = now
+
+	err := internalerrors.ValidateStruct(&updated)
+	if err == nil {
+		err = updated.validateTemplates()
+	}
+	if err != nil {
+		return err
+	}
+
+	*c = updated
+	return nil
+}
+
+func queueContacts(contacts []Contact, now time.Time) []Contact {
+	for index := range contacts {
+		if contacts[index].ID == "" {
+			contacts[index].ID = xid.New().String()
+		}
+		contacts[index].Status = ContactQueued
+		contacts[index].UpdatedOn = now
+	}
+	return contacts
+}
+
+func NewCampaign(name string, subject string, content string, contacts []Contact, createdBy string) (*Campaign, error) {
+
+	now := time.Now()
+	contacts = queueContacts(contacts, now)
 
 	campaing := &Campaign{
 		ID:        xid.New().String(),
 		Name:      name,
-		CreatedOn: time.Now(),
+		Subject:   subject,
+		CreatedOn: now,
 		Content:   content,
 		Contacts:  contacts,
 		Status:    Pending,
 		CreatedBy: createdBy,
 	}
 	err := internalerrors.ValidateStruct(campaing)
+	if err == nil {
+		err = campaing.validateTemplates()
+	}
 	if err == nil {
 		return campaing, nil
 	} else {
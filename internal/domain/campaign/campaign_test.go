@@ -1,6 +1,9 @@
 package campaign
 
 import (
+	internalerrors "emailgo/internal/internal-errors"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -10,8 +13,9 @@ import (
 
 var (
 	name      = "Campaign X"
+	subject   = "Hi {{.FirstName}}"
 	content   = "Body Hi!"
-	contacts  = []string{"email1@e.com", "email2@e.com"}
+	contacts  = []Contact{{Email: "email1@e.com", FirstName: "Ana"}, {Email: "email2@e.com", FirstName: "Bia"}}
 	createdBy = "teste@teste.com.br"
 
 	fake = faker.New()
@@ -20,7 +24,7 @@ var (
 )
 
 func setupNewCampaign() {
-	campaignNewCampaign, _ = NewCampaign(name, content, contacts, createdBy)
+	campaignNewCampaign, _ = NewCampaign(name, subject, content, contacts, createdBy)
 }
 
 func Test_NewCampaign_CreateCampaign(t *testing.T) {
@@ -32,6 +36,14 @@ func Test_NewCampaign_CreateCampaign(t *testing.T) {
 	assert.Equal(t, campaignNewCampaign.CreatedBy, createdBy)
 }
 
+func Test_NewCampaign_ContactsStartQueued(t *testing.T) {
+	setupNewCampaign()
+
+	for _, contact := range campaignNewCampaign.Contacts {
+		assert.Equal(t, ContactQueued, contact.Status)
+	}
+}
+
 func Test_NewCapaign_IDIsNotNil(t *testing.T) {
 	setupNewCampaign()
 
@@ -54,49 +66,88 @@ func Test_NewCapaign_CreatedOnMustBeNow(t *testing.T) {
 
 func Test_NewCampaign_MustValidateNameMin(t *testing.T) {
 
-	_, err := NewCampaign("", content, contacts, createdBy)
+	_, err := NewCampaign("", subject, content, contacts, createdBy)
 
 	assert.Equal(t, "name is required with min 5", err.Error())
 }
 
 func Test_NewCampaign_MustValidateNameMax(t *testing.T) {
-	_, err := NewCampaign(fake.Lorem().Text(30), content, contacts, createdBy)
+	_, err := NewCampaign(fake.Lorem().Text(30), subject, content, contacts, createdBy)
 
 	assert.Equal(t, "name is required with max 24", err.Error())
 }
 
 func Test_NewCampaign_MustValidateContentMin(t *testing.T) {
-	_, err := NewCampaign(name, "", contacts, createdBy)
+	_, err := NewCampaign(name, subject, "", contacts, createdBy)
 
 	assert.Equal(t, "content is required with min 5", err.Error())
 }
 
 func Test_NewCampaign_MustValidateContentMax(t *testing.T) {
-	_, err := NewCampaign(name, fake.Lorem().Text(1040), contacts, createdBy)
+	_, err := NewCampaign(name, subject, fake.Lorem().Text(1040), contacts, createdBy)
 
 	assert.Equal(t, "content is required with max 1024", err.Error())
 }
 
 func Test_NewCampaign_MustValidateContactsMin(t *testing.T) {
-	_, err := NewCampaign(name, content, nil, createdBy)
+	_, err := NewCampaign(name, subject, content, nil, createdBy)
 
 	assert.Equal(t, "contacts is required with min 1", err.Error())
 }
 
 func Test_NewCampaign_MustValidateContacts(t *testing.T) {
-	_, err := NewCampaign(name, content, []string{"email_invalid"}, createdBy)
+	_, err := NewCampaign(name, subject, content, []Contact{{Email: "email_invalid"}}, createdBy)
 
 	assert.Equal(t, "email is invalid", err.Error())
 }
 
+func Test_NewCampaign_MustReportEveryInvalidField(t *testing.T) {
+	invalidContacts := []Contact{{Email: "email1@e.com"}, {Email: "email_invalid"}}
+
+	_, err := NewCampaign("", subject, content, invalidContacts, createdBy)
+
+	var validationError *internalerrors.ValidationError
+	assert.True(t, errors.As(err, &validationError))
+	assert.Equal(t, []internalerrors.FieldError{
+		{Field: "name", Rule: "min", Param: "5", Message: "name is required with min 5"},
+		{Field: "contacts[1].email", Rule: "email", Message: "email is invalid"},
+	}, validationError.Fields)
+	assert.Equal(t, "name is required with min 5; email is invalid", err.Error())
+}
+
+func Test_NewCampaign_MustValidateContentTemplate(t *testing.T) {
+	_, err := NewCampaign(name, subject, "Hi {{.FirstName", contacts, createdBy)
+
+	assert.Contains(t, err.Error(), "content has an invalid template")
+}
+
+func Test_NewCampaign_MustValidateSubjectTemplate(t *testing.T) {
+	_, err := NewCampaign(name, "Hi {{", content, contacts, createdBy)
+
+	assert.Contains(t, err.Error(), "subject has an invalid template")
+}
+
+func Test_NewCampaign_MustValidateMergeFieldsOfEveryContact(t *testing.T) {
+	contactsWithFields := []Contact{
+		{Email: "email1@e.com", Fields: map[string]string{"company": "Acme"}},
+		{Email: "email2@e.com"},
+	}
+
+	_, err := NewCampaign(name, subject, "Hello {{.company}}", contactsWithFields, createdBy)
+
+	assert.Contains(t, err.Error(), "content has an invalid template")
+	assert.Contains(t, err.Error(), "company")
+}
+
 func Test_NewCampaign_MustValidateCreatedBy(t *testing.T) {
-	_, err := NewCampaign(name, content, contacts, "")
+	_, err := NewCampaign(name, subject, content, contacts, "")
 
 	assert.Equal(t, "createdby is invalid", err.Error())
 }
 
 func Test_Done_ChangeStatus(t *testing.T) {
 	setupNewCampaign()
+	campaignNewCampaign.Started()
 
 	campaignNewCampaign.Done()
 
@@ -129,8 +180,360 @@ func Test_Delete_ChangeStatus(t *testing.T) {
 
 func Test_Fail_ChangeStatus(t *testing.T) {
 	setupNewCampaign()
+	campaignNewCampaign.Started()
 
 	campaignNewCampaign.Fail()
 
 	assert.Equal(t, Fail, campaignNewCampaign.Status)
 }
+
+func Test_CompleteFromContacts_WhenAnyContactWasSent_StatusIsDone(t *testing.T) {
+	setupNewCampaign()
+	campaignNewCampaign.Started()
+
+	campaignNewCampaign.Contacts[0].Sent()
+	campaignNewCampaign.Contacts[1].Failed(errors.New("550 mailbox unavailable"))
+	campaignNewCampaign.CompleteFromContacts()
+
+	assert.Equal(t, Done, campaignNewCampaign.Status)
+}
+
+func Test_CompleteFromContacts_WhenAllContactsFailed_StatusIsFail(t *testing.T) {
+	setupNewCampaign()
+	campaignNewCampaign.Started()
+
+	for index := range campaignNewCampaign.Contacts {
+		campaignNewCampaign.Contacts[index].Failed(errors.New("550 mailbox unavailable"))
+	}
+	campaignNewCampaign.CompleteFromContacts()
+
+	assert.Equal(t, Fail, campaignNewCampaign.Status)
+	assert.Equal(t, "550 mailbox unavailable", campaignNewCampaign.Contacts[0].Error)
+}
+
+func Test_Render_PersonalizeSubjectAndContent(t *testing.T) {
+	contact := Contact{Email: "email1@e.com", FirstName: "Ana", Fields: map[string]string{"company": "<Acme>"}}
+	campaign, _ := NewCampaign(name, subject, "Hello {{.FirstName}} from {{.company}}", []Contact{contact}, createdBy)
+
+	subjectRendered, contentRendered, err := campaign.Render(&campaign.Contacts[0])
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Hi Ana", subjectRendered)
+	assert.Equal(t, "Hello Ana from &lt;Acme&gt;", contentRendered)
+}
+
+func Test_Render_WithoutSubject_UseName(t *testing.T) {
+	campaign, _ := NewCampaign(name, "", content, []Contact{{Email: "email1@e.com"}}, createdBy)
+
+	subjectRendered, _, _ := campaign.Render(&campaign.Contacts[0])
+
+	assert.Equal(t, name, subjectRendered)
+}
+
+func Test_Schedule_ChangeStatusAndScheduledFor(t *testing.T) {
+	setupNewCampaign()
+	scheduledFor := time.Now().Add(time.Hour)
+
+	err := campaignNewCampaign.Schedule(scheduledFor)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Scheduled, campaignNewCampaign.Status)
+	assert.Equal(t, scheduledFor, *campaignNewCampaign.ScheduledFor)
+}
+
+func Test_Schedule_MustBeInTheFuture(t *testing.T) {
+	setupNewCampaign()
+
+	err := campaignNewCampaign.Schedule(time.Now().Add(-time.Minute))
+
+	assert.Equal(t, "scheduledfor must be in the future", err.Error())
+	assert.Equal(t, Pending, campaignNewCampaign.Status)
+}
+
+func Test_Schedule_CampaignIsStarted_ErrStatusInvalid(t *testing.T) {
+	setupNewCampaign()
+	campaignNewCampaign.Started()
+
+	err := campaignNewCampaign.Schedule(time.Now().Add(time.Hour))
+
+	assert.True(t, errors.Is(err, ErrStatusInvalid))
+	assert.Equal(t, Started, campaignNewCampaign.Status)
+}
+
+func Test_Unschedule_ChangeStatusToPending(t *testing.T) {
+	setupNewCampaign()
+	campaignNewCampaign.Schedule(time.Now().Add(time.Hour))
+
+	campaignNewCampaign.Unschedule()
+
+	assert.Equal(t, Pending, campaignNewCampaign.Status)
+	assert.Nil(t, campaignNewCampaign.ScheduledFor)
+}
+
+func Test_Done_CampaignIsPending_ErrStatusInvalid(t *testing.T) {
+	setupNewCampaign()
+
+	err := campaignNewCampaign.Done()
+
+	var transitionError *StatusTransitionError
+	assert.True(t, errors.As(err, &transitionError))
+	assert.Equal(t, Pending, transitionError.From)
+	assert.Equal(t, Done, transitionError.To)
+	assert.True(t, errors.Is(err, ErrStatusInvalid))
+	assert.Equal(t, Pending, campaignNewCampaign.Status)
+}
+
+func Test_Pause_ChangeStatus(t *testing.T) {
+	setupNewCampaign()
+	campaignNewCampaign.Started()
+
+	err := campaignNewCampaign.Pause()
+
+	assert.Nil(t, err)
+	assert.Equal(t, Paused, campaignNewCampaign.Status)
+}
+
+func Test_Pause_CampaignIsPending_ErrStatusInvalid(t *testing.T) {
+	setupNewCampaign()
+
+	err := campaignNewCampaign.Pause()
+
+	assert.True(t, errors.Is(err, ErrStatusInvalid))
+}
+
+func Test_Resume_WasStarted_StatusIsStarted(t *testing.T) {
+	setupNewCampaign()
+	campaignNewCampaign.Started()
+	campaignNewCampaign.Pause()
+
+	err := campaignNewCampaign.Resume()
+
+	assert.Nil(t, err)
+	assert.Equal(t, Started, campaignNewCampaign.Status)
+}
+
+func Test_Resume_WasScheduled_StatusIsScheduled(t *testing.T) {
+	setupNewCampaign()
+	campaignNewCampaign.Schedule(time.Now().Add(time.Hour))
+	campaignNewCampaign.Pause()
+
+	err := campaignNewCampaign.Resume()
+
+	assert.Nil(t, err)
+	assert.Equal(t, Scheduled, campaignNewCampaign.Status)
+}
+
+func Test_Resume_CampaignIsNotPaused_ErrStatusInvalid(t *testing.T) {
+	setupNewCampaign()
+
+	err := campaignNewCampaign.Resume()
+
+	assert.True(t, errors.Is(err, ErrStatusInvalid))
+}
+
+func Test_Cancel_CampaignIsDone_ErrStatusInvalid(t *testing.T) {
+	setupNewCampaign()
+	campaignNewCampaign.Started()
+	campaignNewCampaign.Done()
+
+	err := campaignNewCampaign.Cancel()
+
+	assert.True(t, errors.Is(err, ErrStatusInvalid))
+	assert.Equal(t, Done, campaignNewCampaign.Status)
+}
+
+func Test_CanChangeStatus_FinalStatusesHaveNoTransition(t *testing.T) {
+	for _, from := range []string{Done, Canceled, Deleted, Fail} {
+		for _, to := range []string{Pending, Scheduled, Started, Paused, Done, Canceled, Deleted, Fail} {
+			assert.False(t, CanChangeStatus(from, to), from+" -> "+to)
+		}
+	}
+}
+
+func Test_Update_ChangeFieldsAndUpdatedOn(t *testing.T) {
+	setupNewCampaign()
+	now := time.Now().Add(-time.Minute)
+
+	err := campaignNewCampaign.Update("Campaign Y", "Hey {{.FirstName}}", "New body", []Contact{{Email: "email3@e.com"}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Campaign Y", campaignNewCampaign.Name)
+	assert.Equal(t, "Hey {{.FirstName}}", campaignNewCampaign.Subject)
+	assert.Equal(t, "New body", campaignNewCampaign.Content)
+	assert.Len(t, campaignNewCampaign.Contacts, 1)
+	assert.NotEmpty(t, campaignNewCampaign.Contacts[0].ID)
+	assert.Equal(t, ContactQueued, campaignNewCampaign.Contacts[0].Status)
+	assert.Greater(t, campaignNewCampaign.UpdatedOn, now)
+}
+
+func Test_Update_KeepContactIds(t *testing.T) {
+	setupNewCampaign()
+	contactId := campaignNewCampaign.Contacts[0].ID
+
+	campaignNewCampaign.Update(name, subject, "New body", campaignNewCampaign.Contacts)
+
+	assert.Equal(t, contactId, campaignNewCampaign.Contacts[0].ID)
+}
+
+func Test_Update_MustValidate(t *testing.T) {
+	setupNewCampaign()
+
+	err := campaignNewCampaign.Update("", subject, content, contacts)
+
+	assert.Equal(t, "name is required with min 5", err.Error())
+	assert.Equal(t, name, campaignNewCampaign.Name)
+}
+
+func Test_Update_MustValidateTemplates(t *testing.T) {
+	setupNewCampaign()
+
+	err := campaignNewCampaign.Update(name, subject, "Hi {{.FirstName", contacts)
+
+	assert.Contains(t, err.Error(), "content has an invalid template")
+	assert.Equal(t, content, campaignNewCampaign.Content)
+}
+
+func Test_Update_CampaignIsNotPending_ErrStatusInvalid(t *testing.T) {
+	setupNewCampaign()
+	campaignNewCampaign.Started()
+
+	err := campaignNewCampaign.Update(name, subject, "New body", contacts)
+
+	assert.True(t, errors.Is(err, ErrStatusInvalid))
+	assert.Equal(t, content, campaignNewCampaign.Content)
+}
+
+func Test_CompleteFromContacts_ContactPending_StatusIsNotChanged(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+	campaign.Started()
+	campaign.Contacts[0].Deferred(errors.New("451 greylisted"), time.Now().Add(time.Minute))
+
+	err := campaign.CompleteFromContacts()
+
+	assert.Nil(t, err)
+	assert.Equal(t, Started, campaign.Status)
+}
+
+func Test_RetryPolicy_BackoffDoublesUpToMax(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Minute, MaxBackoff: 5 * time.Minute}
+
+	assert.Equal(t, time.Minute, policy.Backoff(1))
+	assert.Equal(t, 2*time.Minute, policy.Backoff(2))
+	assert.Equal(t, 4*time.Minute, policy.Backoff(3))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(4))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(9))
+}
+
+func Test_IsTemporary_UnclassifiedError_False(t *testing.T) {
+	assert.False(t, IsTemporary(errors.New("boom")))
+	assert.True(t, IsTemporary(fmt.Errorf("wrapped: %w", &DeliveryError{Temporary: true, Err: errors.New("451")})))
+}
+
+func Test_Claim_StartedCampaign_StatusIsSendingWithLease(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+	campaign.Started()
+	leaseUntil := time.Now().Add(time.Minute)
+
+	err := campaign.Claim("worker-1", leaseUntil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Sending, campaign.Status)
+	assert.Equal(t, "worker-1", campaign.LeaseOwner)
+	assert.Equal(t, leaseUntil, *campaign.LeaseExpiresOn)
+}
+
+func Test_Claim_SendingCampaign_TakeOverLease(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+	campaign.Started()
+	campaign.Claim("worker-1", time.Now().Add(-time.Minute))
+
+	err := campaign.Claim("worker-2", time.Now().Add(time.Minute))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "worker-2", campaign.LeaseOwner)
+}
+
+func Test_Release_ContactsDeferred_SendAtIsTheFirstNextAttempt(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+	campaign.Started()
+	campaign.Claim("worker-1", time.Now().Add(time.Minute))
+	first := time.Now().Add(time.Minute)
+	campaign.Contacts[0].Deferred(errors.New("451 try later"), first.Add(time.Hour))
+	campaign.Contacts[1].Deferred(errors.New("451 try later"), first)
+
+	err := campaign.Release()
+
+	assert.Nil(t, err)
+	assert.Equal(t, Started, campaign.Status)
+	assert.Equal(t, first, *campaign.SendAt)
+}
+
+func Test_Claim_PendingCampaign_ErrStatusInvalid(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+
+	err := campaign.Claim("worker-1", time.Now().Add(time.Minute))
+
+	assert.True(t, errors.Is(err, ErrStatusInvalid))
+}
+
+func Test_Pause_SendingCampaign_DropLease(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+	campaign.Started()
+	campaign.Claim("worker-1", time.Now().Add(time.Minute))
+
+	err := campaign.Pause()
+
+	assert.Nil(t, err)
+	assert.Equal(t, Paused, campaign.Status)
+	assert.Empty(t, campaign.LeaseOwner)
+	assert.Nil(t, campaign.LeaseExpiresOn)
+}
+
+func Test_Started_SendAtIsAfterGracePeriod(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+	campaign.SetGracePeriod(60)
+
+	campaign.Started()
+
+	assert.Equal(t, campaign.UpdatedOn.Add(time.Minute), *campaign.SendAt)
+}
+
+func Test_Undo_NothingDispatched_StatusIsPending(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+	campaign.Started()
+
+	err := campaign.Undo()
+
+	assert.Nil(t, err)
+	assert.Equal(t, Pending, campaign.Status)
+	assert.Nil(t, campaign.SendAt)
+}
+
+func Test_Undo_ContactWasAttempted_ErrStatusInvalid(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+	campaign.Started()
+	campaign.Contacts[0].Attempts = 1
+
+	err := campaign.Undo()
+
+	assert.True(t, errors.Is(err, ErrStatusInvalid))
+	assert.Equal(t, Started, campaign.Status)
+}
+
+func Test_Undo_CampaignIsSending_ErrStatusInvalid(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+	campaign.Started()
+	campaign.Claim("worker-1", time.Now().Add(time.Minute))
+
+	err := campaign.Undo()
+
+	assert.True(t, errors.Is(err, ErrStatusInvalid))
+}
+
+func Test_SetGracePeriod_OutOfRange_Err(t *testing.T) {
+	campaign, _ := NewCampaign(name, subject, content, contacts, createdBy)
+
+	err := campaign.SetGracePeriod(MaxGracePeriod + 1)
+
+	assert.Equal(t, "graceperiod must be between 0 and 86400", err.Error())
+}
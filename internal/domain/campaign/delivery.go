@@ -0,0 +1,79 @@
+package campaign
+
+import (
+	"errors"
+	"time"
+)
+
+// DeliveryError is returned by a Mailer when a message was not delivered.
+// Temporary errors (greylisting, a full mailbox, a timeout or a dropped
+// connection) are worth retrying; the others will fail again.
+type DeliveryError struct {
+	// Code is the SMTP or HTTP status code, zero when there was no answer.
+	Code      int
+	Temporary bool
+	Err       error
+}
+
+func (e *DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// IsTemporary reports whether a delivery error is worth retrying. Errors a
+// Mailer did not classify are treated as permanent.
+func IsTemporary(err error) bool {
+	var deliveryError *DeliveryError
+	return errors.As(err, &deliveryError) && deliveryError.Temporary
+}
+
+func deliveryCode(err error) int {
+	var deliveryError *DeliveryError
+	if errors.As(err, &deliveryError) {
+		return deliveryError.Code
+	}
+	return 0
+}
+
+// DeliveryAttempt records one try to deliver a campaign to a contact.
+type DeliveryAttempt struct {
+	ID          uint   `gorm:"primaryKey"`
+	CampaignId  string `gorm:"size:50;index"`
+	ContactId   string `gorm:"size:50;index"`
+	Attempt     int
+	AttemptedOn time.Time
+	Code        int
+	Temporary   bool
+	Error       string `gorm:"size:1024"`
+}
+
+// RetryPolicy says how often and how long after a temporary failure a
+// contact is tried again. The wait doubles after each attempt.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Hour,
+	}
+}
+
+// Backoff returns how long to wait after the given failed attempt.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff == 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,50 @@
+package campaign
+
+import (
+	"time"
+
+	"github.com/rs/xid"
+)
+
+const (
+	// DeliveryInFlight is written right before the SMTP transaction. An entry
+	// still in flight after a crash means the server may or may not have
+	// accepted the message.
+	DeliveryInFlight = "InFlight"
+	DeliveryAccepted = "Accepted"
+	DeliveryRejected = "Rejected"
+)
+
+// Delivery is the ledger entry of a campaign for one contact. It outlives
+// worker crashes, so a resumed campaign never sends again to a contact the
+// server already accepted.
+type Delivery struct {
+	CampaignId string `gorm:"primaryKey;size:50"`
+	ContactId  string `gorm:"primaryKey;size:50"`
+	MessageId  string `gorm:"size:255;not null"`
+	Status     string `gorm:"size:20;not null"`
+	StartedOn  time.Time
+	FinishedOn *time.Time
+}
+
+// NewMessageId returns a Message-ID header value unique to one message.
+func NewMessageId(domain string) string {
+	return "<" + xid.New().String() + "@" + domain + ">"
+}
+
+// start marks the delivery as about to be handed to the mail server. The
+// Message-ID is kept across attempts, so a message resent after a crash
+// carries the same Message-ID and receivers can drop the duplicate.
+func (d *Delivery) start(now time.Time) {
+	d.Status = DeliveryInFlight
+	d.StartedOn = now
+	d.FinishedOn = nil
+}
+
+func (d *Delivery) finish(err error, now time.Time) {
+	d.Status = DeliveryAccepted
+	if err != nil {
+		d.Status = DeliveryRejected
+	}
+	d.FinishedOn = &now
+}
@@ -0,0 +1,121 @@
+package campaign
+
+import (
+	"emailgo/internal/contract"
+	internalerrors "emailgo/internal/internal-errors"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+const (
+	SortByCreatedOn = "createdOn"
+	SortByName      = "name"
+
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListFilter selects, orders and pages the campaigns returned by
+// Repository.Get. Empty fields do not filter.
+type ListFilter struct {
+	Status      string
+	CreatedBy   string
+	Name        string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	SortBy      string
+	Descending  bool
+	After       *Cursor
+	Limit       int
+}
+
+// Cursor points at the last campaign of a page: the value of the sort column
+// and the campaign ID, which breaks ties between equal values.
+type Cursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func NewCursor(campaign *Campaign, sortBy string) *Cursor {
+	cursor := &Cursor{ID: campaign.ID}
+	if sortBy == SortByName {
+		cursor.Value = campaign.Name
+	} else {
+		cursor.Value = campaign.CreatedOn.UTC().Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+func (c *Cursor) Encode() string {
+	value, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+// invalidQuery is the error of a list parameter that has a value it does
+// not accept.
+func invalidQuery(field string) error {
+	return internalerrors.NewFieldError(field, "invalid", "", field+" is invalid")
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	errInvalid := invalidQuery("cursor")
+
+	value, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	var cursor Cursor
+	if err = json.Unmarshal(value, &cursor); err != nil || cursor.ID == "" {
+		return nil, errInvalid
+	}
+
+	return &cursor, nil
+}
+
+func newListFilter(request contract.ListCampaignsRequest) (ListFilter, error) {
+	filter := ListFilter{
+		Status:      request.Status,
+		CreatedBy:   request.CreatedBy,
+		Name:        request.Name,
+		CreatedFrom: request.CreatedFrom,
+		CreatedTo:   request.CreatedTo,
+		SortBy:      request.Sort,
+		Limit:       request.Limit,
+	}
+
+	switch filter.SortBy {
+	case "":
+		filter.SortBy = SortByCreatedOn
+	case SortByCreatedOn, SortByName:
+	default:
+		return filter, invalidQuery("sort")
+	}
+
+	switch request.Order {
+	case "":
+		filter.Descending = filter.SortBy == SortByCreatedOn
+	case "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, invalidQuery("order")
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = DefaultListLimit
+	} else if filter.Limit < 0 || filter.Limit > MaxListLimit {
+		return filter, invalidQuery("limit")
+	}
+
+	if request.Cursor != "" {
+		cursor, err := DecodeCursor(request.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,16 @@
+package campaign
+
+import "context"
+
+// Mailer delivers the email of a campaign to one of its contacts. Send gives
+// up when ctx is done, reporting it as a temporary failure.
+type Mailer interface {
+	Send(ctx context.Context, campaign *Campaign, contact *Contact) error
+}
+
+// MailerFunc lets an ordinary function be used as a Mailer.
+type MailerFunc func(ctx context.Context, campaign *Campaign, contact *Contact) error
+
+func (f MailerFunc) Send(ctx context.Context, campaign *Campaign, contact *Contact) error {
+	return f(ctx, campaign, contact)
+}
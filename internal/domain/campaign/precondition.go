@@ -0,0 +1,22 @@
+package campaign
+
+import (
+	"context"
+	"slices"
+)
+
+type expectedVersionsKey struct{}
+
+// WithExpectedVersions returns a context under which the service only
+// changes a campaign whose version is one of versions, the way an If-Match
+// header asks. With no versions no campaign matches.
+func WithExpectedVersions(ctx context.Context, versions ...int) context.Context {
+	return context.WithValue(ctx, expectedVersionsKey{}, versions)
+}
+
+// matchesExpectedVersion reports whether the campaign is at a version ctx
+// expects, which is always the case when ctx expects none in particular.
+func matchesExpectedVersion(ctx context.Context, campaign *Campaign) bool {
+	versions, ok := ctx.Value(expectedVersionsKey{}).([]int)
+	return !ok || slices.Contains(versions, campaign.Version)
+}
@@ -1,10 +1,38 @@
 package campaign
 
+import (
+	"context"
+	"time"
+)
+
+// Repository saves campaigns. Update, UpdateStatus and Delete only change a
+// campaign still at the Version it was read with and return
+// internalerrors.ErrConflict otherwise. Every saved change, claims and
+// releases included, increments Version.
 type Repository interface {
-	Create(campaign *Campaign) error
-	Update(campaign *Campaign) error
-	Get() ([]Campaign, error)
-	GetBy(id string) (*Campaign, error)
-	Delete(campaign *Campaign) error
-	GetCampaignsToBeSent() ([]Campaign, error)
+	Create(ctx context.Context, campaign *Campaign) error
+	Update(ctx context.Context, campaign *Campaign) error
+	// UpdateStatus saves the status, schedule and lease of the campaign
+	// without touching its contacts.
+	UpdateStatus(ctx context.Context, campaign *Campaign) error
+	UpdateContact(ctx context.Context, contact *Contact) error
+	Get(ctx context.Context, filter ListFilter) ([]Campaign, error)
+	GetBy(ctx context.Context, id string) (*Campaign, error)
+	Delete(ctx context.Context, campaign *Campaign) error
+	// Claim leases up to limit campaigns that are due to be sent to owner:
+	// started or scheduled campaigns whose send time has come and campaigns
+	// whose lease expired. A campaign is never leased to two owners at the
+	// same time.
+	Claim(ctx context.Context, owner string, leaseUntil time.Time, limit int) ([]Campaign, error)
+	// RenewLease extends the lease of a campaign the owner still holds and
+	// returns ErrLeaseLost otherwise.
+	RenewLease(ctx context.Context, campaign *Campaign, owner string) error
+	// Release saves the status of a campaign the owner still holds, ending
+	// its lease, and returns ErrLeaseLost otherwise, including when the
+	// campaign was changed since it was claimed.
+	Release(ctx context.Context, campaign *Campaign, owner string) error
+	// Notify tells the workers that a campaign was started.
+	Notify(ctx context.Context, campaign *Campaign) error
+	GetDeliveries(ctx context.Context, campaignId string) ([]Delivery, error)
+	SaveDelivery(ctx context.Context, delivery *Delivery) error
 }
@@ -1,30 +1,76 @@
 package campaign
 
 import (
+	"context"
 	"emailgo/internal/contract"
 	internalerrors "emailgo/internal/internal-errors"
 	"errors"
+	"time"
 )
 
 type Service interface {
-	Create(newCampaign contract.NewCampaignRequest) (string, error)
-	GetBy(id string) (*contract.CampaignResponse, error)
-	Delete(id string) error
-	Start(id string) error
+	Create(ctx context.Context, newCampaign contract.NewCampaignRequest) (string, error)
+	List(ctx context.Context, request contract.ListCampaignsRequest, caller Caller) (*contract.CampaignListResponse, error)
+	GetBy(ctx context.Context, id string, caller Caller) (*contract.CampaignResponse, error)
+	Update(ctx context.Context, id string, request contract.UpdateCampaignRequest, caller Caller) error
+	Delete(ctx context.Context, id string, caller Caller) error
+	Start(ctx context.Context, id string, caller Caller) error
+	Schedule(ctx context.Context, id string, request contract.ScheduleCampaignRequest, caller Caller) error
+	Unschedule(ctx context.Context, id string, caller Caller) error
+	Cancel(ctx context.Context, id string, caller Caller) error
+	Pause(ctx context.Context, id string, caller Caller) error
+	Resume(ctx context.Context, id string, caller Caller) error
+	Undo(ctx context.Context, id string, caller Caller) error
 }
 
 type ServiceImp struct {
 	Repository Repository
-	SendMail   func(campaign *Campaign) error
+	Mailer     Mailer
+	// RetryPolicy defaults to DefaultRetryPolicy when MaxAttempts is zero.
+	RetryPolicy RetryPolicy
+	// WorkerID identifies this process in campaign leases.
+	WorkerID string
+	// LeaseDuration defaults to DefaultLeaseDuration.
+	LeaseDuration time.Duration
+	// MessageIdDomain is the right side of generated Message-IDs, usually
+	// the domain of the sender address.
+	MessageIdDomain string
+	// GracePeriod is given to campaigns created without one.
+	GracePeriod time.Duration
+	// SendTimeout bounds the delivery of one message. Zero means no bound
+	// besides the one of the mailer.
+	SendTimeout time.Duration
 }
 
-func (s *ServiceImp) Create(newCampaign contract.NewCampaignRequest) (string, error) {
-	campaign, err := NewCampaign(newCampaign.Name, newCampaign.Content, newCampaign.Emails, newCampaign.CreatedBy)
+const DefaultLeaseDuration = 2 * time.Minute
+
+// DefaultGracePeriod is the grace period the API gives campaigns created
+// without one, unless configured otherwise.
+const DefaultGracePeriod = time.Minute
+
+func (s *ServiceImp) Create(ctx context.Context, newCampaign contract.NewCampaignRequest) (string, error) {
+	campaign, err := NewCampaign(newCampaign.Name, newCampaign.Subject, newCampaign.Content, contactsFrom(newCampaign), newCampaign.CreatedBy)
 	if err != nil {
 		return "", err
 	}
 
-	err = s.Repository.Create(campaign)
+	gracePeriod := int(s.GracePeriod / time.Second)
+	if newCampaign.GracePeriod != nil {
+		gracePeriod = *newCampaign.GracePeriod
+	}
+	err = campaign.SetGracePeriod(gracePeriod)
+	if err != nil {
+		return "", err
+	}
+
+	if newCampaign.ScheduledFor != nil {
+		err = campaign.Schedule(*newCampaign.ScheduledFor)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = s.Repository.Create(ctx, campaign)
 	if err != nil {
 		return "", internalerrors.ErrInternal
 	}
@@ -32,71 +78,362 @@ func (s *ServiceImp) Create(newCampaign contract.NewCampaignRequest) (string, er
 	return campaign.ID, nil
 }
 
-func (s *ServiceImp) GetBy(id string) (*contract.CampaignResponse, error) {
-	campaign, err := s.Repository.GetBy(id)
+func contactsFrom(newCampaign contract.NewCampaignRequest) []Contact {
+	return newContacts(newCampaign.Emails, newCampaign.Contacts)
+}
+
+func newContacts(emails []string, contactRequests []contract.ContactRequest) []Contact {
+	contacts := make([]Contact, 0, len(emails)+len(contactRequests))
+	for _, email := range emails {
+		contacts = append(contacts, Contact{Email: email})
+	}
+	for _, contact := range contactRequests {
+		contacts = append(contacts, Contact{
+			Email:     contact.Email,
+			FirstName: contact.FirstName,
+			LastName:  contact.LastName,
+			Fields:    contact.Fields,
+		})
+	}
+	return contacts
+}
+
+// getOwnedBy loads a campaign the caller is allowed to see and change, at
+// the version ctx expects.
+func (s *ServiceImp) getOwnedBy(ctx context.Context, id string, caller Caller) (*Campaign, error) {
+	campaign, err := s.Repository.GetBy(ctx, id)
 
 	if err != nil {
 		return nil, internalerrors.ProcessErrorToReturn(err)
 	}
 
-	return &contract.CampaignResponse{
+	if !caller.Owns(campaign) {
+		return nil, internalerrors.ErrForbidden
+	}
+
+	if !matchesExpectedVersion(ctx, campaign) {
+		return nil, internalerrors.ErrPreconditionFailed
+	}
+
+	return campaign, nil
+}
+
+// saveError hides repository failures from clients, except conflicts, which
+// they can resolve by reading the campaign again.
+func saveError(err error) error {
+	if errors.Is(err, internalerrors.ErrConflict) {
+		return err
+	}
+	return internalerrors.ErrInternal
+}
+
+func (s *ServiceImp) GetBy(ctx context.Context, id string, caller Caller) (*contract.CampaignResponse, error) {
+	campaign, err := s.getOwnedBy(ctx, id, caller)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := newCampaignResponse(campaign)
+	return &response, nil
+}
+
+func newCampaignResponse(campaign *Campaign) contract.CampaignResponse {
+	return contract.CampaignResponse{
 		ID:                   campaign.ID,
 		Name:                 campaign.Name,
+		Subject:              campaign.Subject,
 		Content:              campaign.Content,
 		Status:               campaign.Status,
 		AmountOfEmailsToSend: len(campaign.Contacts),
 		CreatedBy:            campaign.CreatedBy,
-	}, nil
+		ScheduledFor:         campaign.ScheduledFor,
+		GracePeriod:          campaign.GracePeriod,
+		SendingAt:            campaign.SendAt,
+		Version:              campaign.Version,
+	}
 }
 
-func (s *ServiceImp) Delete(id string) error {
+func (s *ServiceImp) List(ctx context.Context, request contract.ListCampaignsRequest, caller Caller) (*contract.CampaignListResponse, error) {
+	filter, err := newListFilter(request)
+	if err != nil {
+		return nil, err
+	}
 
-	campaignSaved, err := s.Repository.GetBy(id)
+	if !caller.Admin {
+		filter.CreatedBy = caller.Email
+	}
 
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	campaigns, err := s.Repository.Get(ctx, filter)
 	if err != nil {
-		return internalerrors.ProcessErrorToReturn(err)
+		return nil, internalerrors.ErrInternal
 	}
 
-	if campaignSaved.Status != Pending {
-		return errors.New("Campaign status invalid")
+	response := &contract.CampaignListResponse{Campaigns: []contract.CampaignResponse{}}
+	if len(campaigns) > limit {
+		campaigns = campaigns[:limit]
+		response.NextCursor = NewCursor(&campaigns[limit-1], filter.SortBy).Encode()
 	}
+	for index := range campaigns {
+		response.Campaigns = append(response.Campaigns, newCampaignResponse(&campaigns[index]))
+	}
+
+	return response, nil
+}
+
+func (s *ServiceImp) Update(ctx context.Context, id string, request contract.UpdateCampaignRequest, caller Caller) error {
+	campaignSaved, err := s.getOwnedBy(ctx, id, caller)
 
-	campaignSaved.Delete()
-	err = s.Repository.Delete(campaignSaved)
 	if err != nil {
-		return internalerrors.ErrInternal
+		return err
+	}
+
+	name, subject, content, contacts := campaignSaved.Name, campaignSaved.Subject, campaignSaved.Content, campaignSaved.Contacts
+	if request.Name != nil {
+		name = *request.Name
+	}
+	if request.Subject != nil {
+		subject = *request.Subject
+	}
+	if request.Content != nil {
+		content = *request.Content
+	}
+	if request.Emails != nil || request.Contacts != nil {
+		contacts = newContacts(request.Emails, request.Contacts)
+	}
+
+	if request.GracePeriod != nil {
+		err = campaignSaved.SetGracePeriod(*request.GracePeriod)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = campaignSaved.Update(name, subject, content, contacts)
+	if err != nil {
+		return err
+	}
+
+	err = s.Repository.Update(ctx, campaignSaved)
+	if err != nil {
+		return saveError(err)
 	}
 
 	return nil
 }
 
-func (s *ServiceImp) SendEmailAndUpdateStatus(campaignSaved *Campaign) {
-	err := s.SendMail(campaignSaved)
+func (s *ServiceImp) Delete(ctx context.Context, id string, caller Caller) error {
+
+	campaignSaved, err := s.getOwnedBy(ctx, id, caller)
+
+	if err != nil {
+		return err
+	}
+
+	err = campaignSaved.Delete()
+	if err != nil {
+		return err
+	}
+
+	err = s.Repository.Delete(ctx, campaignSaved)
 	if err != nil {
-		campaignSaved.Fail()
+		return saveError(err)
+	}
+
+	return nil
+}
+
+func (s *ServiceImp) leaseUntil() time.Time {
+	if s.LeaseDuration == 0 {
+		return time.Now().Add(DefaultLeaseDuration)
+	}
+	return time.Now().Add(s.LeaseDuration)
+}
+
+// ClaimCampaignsToBeSent leases up to limit due campaigns to this worker.
+// Started campaigns are only due once their grace period is over.
+func (s *ServiceImp) ClaimCampaignsToBeSent(ctx context.Context, limit int) ([]Campaign, error) {
+	return s.Repository.Claim(ctx, s.WorkerID, s.leaseUntil(), limit)
+}
+
+// SendEmailAndUpdateStatus sends a claimed campaign to every contact that is
+// due. Temporary failures are retried on a later run following the retry
+// policy, so the campaign is only completed once no contact is left waiting.
+// The lease is renewed before each contact and each result is saved right
+// away; when the lease is lost the worker stops and returns ErrLeaseLost.
+// When ctx is done the send in progress is abandoned as a temporary failure
+// and the campaign is checkpointed. Results are saved even after ctx is done,
+// so a message the server accepted is never forgotten.
+func (s *ServiceImp) SendEmailAndUpdateStatus(ctx context.Context, campaignSaved *Campaign) error {
+	saveCtx := context.WithoutCancel(ctx)
+	policy := s.RetryPolicy
+	if policy.MaxAttempts == 0 {
+		policy = DefaultRetryPolicy()
+	}
+
+	ledger, err := s.Repository.GetDeliveries(ctx, campaignSaved.ID)
+	if err != nil {
+		return err
+	}
+	deliveries := make(map[string]*Delivery, len(ledger))
+	for index := range ledger {
+		deliveries[ledger[index].ContactId] = &ledger[index]
+	}
+
+	for index := range campaignSaved.Contacts {
+		contact := &campaignSaved.Contacts[index]
+		now := time.Now()
+		if !contact.Pending() || (contact.NextAttemptOn != nil && contact.NextAttemptOn.After(now)) {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			if err := s.Checkpoint(saveCtx, campaignSaved); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+
+		leaseUntil := s.leaseUntil()
+		campaignSaved.LeaseExpiresOn = &leaseUntil
+		if err := s.Repository.RenewLease(ctx, campaignSaved, s.WorkerID); err != nil {
+			return err
+		}
+
+		if err := s.deliver(ctx, campaignSaved, contact, deliveries[contact.ID], policy); err != nil {
+			return err
+		}
+	}
+
+	return s.Checkpoint(saveCtx, campaignSaved)
+}
+
+// Checkpoint gives back the lease of a claimed campaign, keeping what was
+// sent so far. The campaign is completed when no contact is left waiting and
+// is picked up again by a later claim otherwise.
+func (s *ServiceImp) Checkpoint(ctx context.Context, campaignSaved *Campaign) error {
+	if err := campaignSaved.Release(); err != nil {
+		return err
+	}
+	return s.Repository.Release(ctx, campaignSaved, s.WorkerID)
+}
+
+// deliver sends the campaign to one contact, writing the ledger entry before
+// and after the SMTP transaction. A contact the ledger shows as accepted by
+// an earlier run that crashed is only marked as sent. What happens after the
+// send is saved with ctx cancellation removed.
+func (s *ServiceImp) deliver(ctx context.Context, campaignSaved *Campaign, contact *Contact, delivery *Delivery, policy RetryPolicy) error {
+	if delivery != nil && delivery.Status == DeliveryAccepted {
+		contact.Sent()
+		return s.Repository.UpdateContact(ctx, contact)
+	}
+
+	if delivery == nil {
+		delivery = &Delivery{CampaignId: campaignSaved.ID, ContactId: contact.ID, MessageId: NewMessageId(s.messageIdDomain())}
+	}
+	contact.MessageId = delivery.MessageId
+
+	now := time.Now()
+	delivery.start(now)
+	if err := s.Repository.SaveDelivery(ctx, delivery); err != nil {
+		return err
+	}
+
+	sendCtx, cancel := s.sendContext(ctx)
+	err := s.Mailer.Send(sendCtx, campaignSaved, contact)
+	cancel()
+
+	ctx = context.WithoutCancel(ctx)
+	delivery.finish(err, time.Now())
+	if err := s.Repository.SaveDelivery(ctx, delivery); err != nil {
+		return err
+	}
+
+	contact.recordAttempt(err, now)
+	if err == nil {
+		contact.Sent()
+	} else if IsTemporary(err) && contact.Attempts < policy.MaxAttempts {
+		contact.Deferred(err, now.Add(policy.Backoff(contact.Attempts)))
 	} else {
-		campaignSaved.Done()
+		contact.Failed(err)
 	}
-	s.Repository.Update(campaignSaved)
 
+	return s.Repository.UpdateContact(ctx, contact)
 }
 
-func (s *ServiceImp) Start(id string) error {
-	campaignSaved, err := s.Repository.GetBy(id)
+// sendContext bounds one send by SendTimeout, when there is one.
+func (s *ServiceImp) sendContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.SendTimeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.SendTimeout)
+}
+
+func (s *ServiceImp) messageIdDomain() string {
+	if s.MessageIdDomain == "" {
+		return "emailgo"
+	}
+	return s.MessageIdDomain
+}
+
+// changeStatus loads the campaign, applies a status change and saves it.
+// Illegal changes are rejected by the campaign before anything is saved, and
+// the change is not saved when the campaign was changed meanwhile.
+// Contacts are not saved, so results a worker is recording are kept. Workers
+// are notified when the campaign becomes Started.
+func (s *ServiceImp) changeStatus(ctx context.Context, id string, caller Caller, change func(campaign *Campaign) error) error {
+	campaignSaved, err := s.getOwnedBy(ctx, id, caller)
 
 	if err != nil {
-		return internalerrors.ProcessErrorToReturn(err)
+		return err
 	}
 
-	if campaignSaved.Status != Pending {
-		return errors.New("Campaign status invalid")
+	err = change(campaignSaved)
+	if err != nil {
+		return err
 	}
 
-	campaignSaved.Started()
-	err = s.Repository.Update(campaignSaved)
+	err = s.Repository.UpdateStatus(ctx, campaignSaved)
 	if err != nil {
-		return internalerrors.ErrInternal
+		return saveError(err)
+	}
+
+	// Workers poll as well, so a lost notification only delays the sending.
+	if campaignSaved.Status == Started {
+		s.Repository.Notify(ctx, campaignSaved)
 	}
 
 	return nil
 }
+
+func (s *ServiceImp) Start(ctx context.Context, id string, caller Caller) error {
+	return s.changeStatus(ctx, id, caller, (*Campaign).Started)
+}
+
+func (s *ServiceImp) Schedule(ctx context.Context, id string, request contract.ScheduleCampaignRequest, caller Caller) error {
+	return s.changeStatus(ctx, id, caller, func(campaign *Campaign) error {
+		return campaign.Schedule(request.ScheduledFor)
+	})
+}
+
+func (s *ServiceImp) Unschedule(ctx context.Context, id string, caller Caller) error {
+	return s.changeStatus(ctx, id, caller, (*Campaign).Unschedule)
+}
+
+func (s *ServiceImp) Cancel(ctx context.Context, id string, caller Caller) error {
+	return s.changeStatus(ctx, id, caller, (*Campaign).Cancel)
+}
+
+func (s *ServiceImp) Pause(ctx context.Context, id string, caller Caller) error {
+	return s.changeStatus(ctx, id, caller, (*Campaign).Pause)
+}
+
+func (s *ServiceImp) Resume(ctx context.Context, id string, caller Caller) error {
+	return s.changeStatus(ctx, id, caller, (*Campaign).Resume)
+}
+
+func (s *ServiceImp) Undo(ctx context.Context, id string, caller Caller) error {
+	return s.changeStatus(ctx, id, caller, (*Campaign).Undo)
+}
@@ -1,12 +1,15 @@
 package campaign_test
 
 import (
+	"context"
 	"emailgo/internal/contract"
 	"emailgo/internal/domain/campaign"
+	"emailgo/internal/infrastructure/memory"
 	internalerrors "emailgo/internal/internal-errors"
 	internalmock "emailgo/internal/test/internalmock"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -23,27 +26,53 @@ var (
 	campaignPendenting, campaignStarted *campaign.Campaign
 	repositoryMock                      *internalmock.CampaignRepositoryMock
 	service                             = campaign.ServiceImp{}
+	owner                               = campaign.Caller{Email: newCampaign.CreatedBy}
 )
 
+// requestKey marks the context of the request, so the mocks can check that
+// it is the one the repository receives.
+type requestKey struct{}
+
+var requestCtx = context.WithValue(context.Background(), requestKey{}, "request")
+
+// fromRequest matches requestCtx and the contexts derived from it.
+var fromRequest = mock.MatchedBy(func(ctx context.Context) bool {
+	return ctx.Value(requestKey{}) == "request"
+})
+
+func contactsOf(emails ...string) []campaign.Contact {
+	contacts := make([]campaign.Contact, len(emails))
+	for index, email := range emails {
+		contacts[index].Email = email
+	}
+	return contacts
+}
+
 func setupServiceTest() {
 	repositoryMock = new(internalmock.CampaignRepositoryMock)
 	service.Repository = repositoryMock
-	campaignPendenting, _ = campaign.NewCampaign(newCampaign.Name, newCampaign.Content, newCampaign.Emails, newCampaign.CreatedBy)
-	campaignStarted = &campaign.Campaign{ID: "1", Status: campaign.Started}
+	campaignPendenting, _ = campaign.NewCampaign(newCampaign.Name, newCampaign.Subject, newCampaign.Content, contactsOf(newCampaign.Emails...), newCampaign.CreatedBy)
+	campaignStarted = &campaign.Campaign{ID: "1", Status: campaign.Started, CreatedBy: newCampaign.CreatedBy}
 }
 
 func setupSendEmailTest(err error) {
-	sendMail := func(campaign *campaign.Campaign) error {
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
 		return err
-	}
-	service.SendMail = sendMail
+	})
+}
+
+func setupSendRepository() {
+	repositoryMock.On("GetDeliveries", fromRequest, mock.Anything).Return([]campaign.Delivery{}, nil)
+	repositoryMock.On("SaveDelivery", fromRequest, mock.Anything).Return(nil)
+	repositoryMock.On("RenewLease", fromRequest, mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("UpdateContact", fromRequest, mock.Anything).Return(nil)
 }
 
 func Test_Create_RequestIsValid_IdIsNotNil(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("Create", mock.Anything).Return(nil)
+	repositoryMock.On("Create", fromRequest, mock.Anything).Return(nil)
 
-	id, err := service.Create(newCampaign)
+	id, err := service.Create(requestCtx, newCampaign)
 
 	assert.NotNil(t, id)
 	assert.Nil(t, err)
@@ -51,41 +80,93 @@ func Test_Create_RequestIsValid_IdIsNotNil(t *testing.T) {
 
 func Test_Create_RequestIsNotValid_ErrInternal(t *testing.T) {
 	setupServiceTest()
-	_, err := service.Create(contract.NewCampaignRequest{})
+	_, err := service.Create(requestCtx, contract.NewCampaignRequest{})
 
 	assert.False(t, errors.Is(internalerrors.ErrInternal, err))
 }
 
 func Test_Create_RequestIsValid_CallRepository(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("Create", mock.MatchedBy(func(campaign *campaign.Campaign) bool {
+	repositoryMock.On("Create", fromRequest, mock.MatchedBy(func(campaign *campaign.Campaign) bool {
 		if campaign.Name != newCampaign.Name || campaign.Content != newCampaign.Content || len(campaign.Contacts) != len(newCampaign.Emails) {
 			return false
 		}
 		return true
 	})).Return(nil)
 
-	service.Create(newCampaign)
+	service.Create(requestCtx, newCampaign)
 
 	repositoryMock.AssertExpectations(t)
 }
 
+func Test_Create_RequestWithContacts_CallRepositoryWithMergeFields(t *testing.T) {
+	setupServiceTest()
+	request := contract.NewCampaignRequest{
+		Name:    "Test Y",
+		Subject: "Hi {{.FirstName}}",
+		Content: "Welcome {{.FirstName}}!",
+		Emails:  []string{"test1@test.com"},
+		Contacts: []contract.ContactRequest{
+			{Email: "test2@test.com", FirstName: "Ana", Fields: map[string]string{"company": "Acme"}},
+		},
+		CreatedBy: "teste@test.com.br",
+	}
+	repositoryMock.On("Create", fromRequest, mock.MatchedBy(func(campaign *campaign.Campaign) bool {
+		return len(campaign.Contacts) == 2 &&
+			campaign.Subject == request.Subject &&
+			campaign.Contacts[1].FirstName == "Ana" &&
+			campaign.Contacts[1].Fields["company"] == "Acme"
+	})).Return(nil)
+
+	_, err := service.Create(requestCtx, request)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Create_RequestWithScheduledFor_StatusIsScheduled(t *testing.T) {
+	setupServiceTest()
+	scheduledFor := time.Now().Add(time.Hour)
+	request := newCampaign
+	request.ScheduledFor = &scheduledFor
+	repositoryMock.On("Create", fromRequest, mock.MatchedBy(func(campaignToCreate *campaign.Campaign) bool {
+		return campaignToCreate.Status == campaign.Scheduled && campaignToCreate.ScheduledFor.Equal(scheduledFor)
+	})).Return(nil)
+
+	_, err := service.Create(requestCtx, request)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Create_RequestWithScheduledForInThePast_Err(t *testing.T) {
+	setupServiceTest()
+	scheduledFor := time.Now().Add(-time.Hour)
+	request := newCampaign
+	request.ScheduledFor = &scheduledFor
+
+	_, err := service.Create(requestCtx, request)
+
+	assert.Equal(t, "scheduledfor must be in the future", err.Error())
+	repositoryMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func Test_Create_ErrorOnRepository_ErrInternal(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("Create", mock.Anything).Return(errors.New("error to save on database"))
+	repositoryMock.On("Create", fromRequest, mock.Anything).Return(errors.New("error to save on database"))
 
-	_, err := service.Create(newCampaign)
+	_, err := service.Create(requestCtx, newCampaign)
 
 	assert.True(t, errors.Is(internalerrors.ErrInternal, err))
 }
 
 func Test_GetById_CampaignExists_CampaignSaved(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("GetBy", mock.MatchedBy(func(id string) bool {
+	repositoryMock.On("GetBy", fromRequest, mock.MatchedBy(func(id string) bool {
 		return id == campaignPendenting.ID
 	})).Return(campaignPendenting, nil)
 
-	campaignReturned, _ := service.GetBy(campaignPendenting.ID)
+	campaignReturned, _ := service.GetBy(requestCtx, campaignPendenting.ID, owner)
 
 	assert.Equal(t, campaignPendenting.ID, campaignReturned.ID)
 	assert.Equal(t, campaignPendenting.Name, campaignReturned.Name)
@@ -96,105 +177,851 @@ func Test_GetById_CampaignExists_CampaignSaved(t *testing.T) {
 
 func Test_GetById_ErrorOnRepository_ErrInternal(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("GetBy", mock.Anything).Return(nil, errors.New("Something wrong"))
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(nil, errors.New("Something wrong"))
 
-	_, err := service.GetBy("invalid campaign")
+	_, err := service.GetBy(requestCtx, "invalid campaign", owner)
 
 	assert.Equal(t, internalerrors.ErrInternal.Error(), err.Error())
 }
 
 func Test_Delete_CampaignWasNotFound_ErrRecordNotFound(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("GetBy", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
 
-	err := service.Delete("invalid campaign")
+	err := service.Delete(requestCtx, "invalid campaign", owner)
 
 	assert.Equal(t, err.Error(), gorm.ErrRecordNotFound.Error())
 }
 
 func Test_Delete_CampaignIsNotPending_Err(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("GetBy", mock.Anything).Return(campaignStarted, nil)
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignStarted, nil)
 
-	err := service.Delete(campaignStarted.ID)
+	err := service.Delete(requestCtx, campaignStarted.ID, owner)
 
-	assert.Equal(t, "Campaign status invalid", err.Error())
+	assert.True(t, errors.Is(err, campaign.ErrStatusInvalid))
 }
 
 func Test_Delete_ErrorOnRepository_ErrInternal(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("GetBy", mock.Anything).Return(campaignPendenting, nil)
-	repositoryMock.On("Delete", mock.Anything).Return(errors.New("error to delete campaign"))
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("Delete", fromRequest, mock.Anything).Return(errors.New("error to delete campaign"))
 
-	err := service.Delete(campaignPendenting.ID)
+	err := service.Delete(requestCtx, campaignPendenting.ID, owner)
 
 	assert.Equal(t, internalerrors.ErrInternal.Error(), err.Error())
 }
 
+func Test_Delete_CampaignWasChangedMeanwhile_ErrConflict(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("Delete", fromRequest, mock.Anything).Return(internalerrors.ErrConflict)
+
+	err := service.Delete(requestCtx, campaignPendenting.ID, owner)
+
+	assert.Equal(t, internalerrors.ErrConflict, err)
+}
+
+func Test_Delete_VersionIsNotExpected_ErrPreconditionFailed(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	ctx := campaign.WithExpectedVersions(requestCtx, campaignPendenting.Version+1)
+
+	err := service.Delete(ctx, campaignPendenting.ID, owner)
+
+	assert.Equal(t, internalerrors.ErrPreconditionFailed, err)
+	repositoryMock.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
 func Test_Delete_CampaignWasDeleted_Nil(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("GetBy", mock.Anything).Return(campaignPendenting, nil)
-	repositoryMock.On("Delete", mock.MatchedBy(func(campaign *campaign.Campaign) bool {
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("Delete", fromRequest, mock.MatchedBy(func(campaign *campaign.Campaign) bool {
 		return campaignPendenting == campaign
 	})).Return(nil)
 
-	err := service.Delete(campaignPendenting.ID)
+	err := service.Delete(requestCtx, campaignPendenting.ID, owner)
 
 	assert.Nil(t, err)
 }
 
 func Test_Start_CamapaignWasNotFound_ErrRecordNotFound(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("GetBy", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
 
-	err := service.Start("campaign invalid")
+	err := service.Start(requestCtx, "campaign invalid", owner)
 
 	assert.Equal(t, err.Error(), gorm.ErrRecordNotFound.Error())
 }
 
 func Test_Start_CampaignIsNotPending_Err(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("GetBy", mock.Anything).Return(campaignStarted, nil)
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignStarted, nil)
 
-	err := service.Start(campaignStarted.ID)
+	err := service.Start(requestCtx, campaignStarted.ID, owner)
 
-	assert.Equal(t, "Campaign status invalid", err.Error())
+	assert.True(t, errors.Is(err, campaign.ErrStatusInvalid))
 }
 
 func Test_Start_CampaignWasUpdated_StatusIsStarted(t *testing.T) {
 	setupServiceTest()
-	repositoryMock.On("GetBy", mock.Anything).Return(campaignPendenting, nil)
-	repositoryMock.On("Update", mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("UpdateStatus", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
 		return campaignPendenting.ID == campaignToUpdate.ID && campaignToUpdate.Status == campaign.Started
 	})).Return(nil)
+	repositoryMock.On("Notify", fromRequest, campaignPendenting).Return(nil)
 
 	setupSendEmailTest(nil)
 
-	service.Start(campaignPendenting.ID)
+	service.Start(requestCtx, campaignPendenting.ID, owner)
 
 	assert.Equal(t, campaign.Started, campaignPendenting.Status)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Start_NotifyFails_Nil(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("UpdateStatus", fromRequest, mock.Anything).Return(nil)
+	repositoryMock.On("Notify", fromRequest, mock.Anything).Return(errors.New("connection refused"))
+
+	err := service.Start(requestCtx, campaignPendenting.ID, owner)
+
+	assert.Nil(t, err)
 }
 
 func Test_SendEmailUpdateStatus_WhenFail_StatusIsFail(t *testing.T) {
 	setupServiceTest()
+	campaignPendenting.Started()
 	setupSendEmailTest(errors.New("error to send email"))
-	repositoryMock.On("Update", mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
 		return campaignPendenting.ID == campaignToUpdate.ID && campaignToUpdate.Status == campaign.Fail
-	})).Return(nil)
+	}), mock.Anything).Return(nil)
 
-	service.SendEmailAndUpdateStatus(campaignPendenting)
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
 
 	repositoryMock.AssertExpectations(t)
 }
 
 func Test_SendEmailUpdateStatus_WhenSuccess_StatusIsDone(t *testing.T) {
 	setupServiceTest()
+	campaignPendenting.Started()
 	setupSendEmailTest(nil)
-	repositoryMock.On("Update", mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
 		return campaignPendenting.ID == campaignToUpdate.ID && campaignToUpdate.Status == campaign.Done
+	}), mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_SendEmailUpdateStatus_MailerReceivesRequestContext(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	var mailerCtx context.Context
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		mailerCtx = ctx
+		return nil
+	})
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	assert.Equal(t, "request", mailerCtx.Value(requestKey{}))
+}
+
+func Test_SendEmailUpdateStatus_SendOneEmailPerContact(t *testing.T) {
+	setupServiceTest()
+	campaignToSend, _ := campaign.NewCampaign(newCampaign.Name, newCampaign.Subject, newCampaign.Content, contactsOf("a@test.com", "b@test.com"), newCampaign.CreatedBy)
+	campaignToSend.Started()
+	var emailsSent []string
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		emailsSent = append(emailsSent, contact.Email)
+		return nil
+	})
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignToSend)
+
+	assert.Equal(t, []string{"a@test.com", "b@test.com"}, emailsSent)
+	for _, contact := range campaignToSend.Contacts {
+		assert.Equal(t, campaign.ContactSent, contact.Status)
+	}
+}
+
+func Test_SendEmailUpdateStatus_WhenOneContactFails_RecordContactErrorAndStatusIsDone(t *testing.T) {
+	setupServiceTest()
+	campaignToSend, _ := campaign.NewCampaign(newCampaign.Name, newCampaign.Subject, newCampaign.Content, contactsOf("a@test.com", "b@test.com"), newCampaign.CreatedBy)
+	campaignToSend.Started()
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		if contact.Email == "b@test.com" {
+			return errors.New("550 mailbox unavailable")
+		}
+		return nil
+	})
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Done
+	}), mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignToSend)
+
+	repositoryMock.AssertExpectations(t)
+	assert.Equal(t, campaign.ContactSent, campaignToSend.Contacts[0].Status)
+	assert.Equal(t, campaign.ContactFailed, campaignToSend.Contacts[1].Status)
+	assert.Equal(t, "550 mailbox unavailable", campaignToSend.Contacts[1].Error)
+}
+
+func Test_SendEmailUpdateStatus_ContactAlreadySent_IsSkipped(t *testing.T) {
+	setupServiceTest()
+	campaignToSend, _ := campaign.NewCampaign(newCampaign.Name, newCampaign.Subject, newCampaign.Content, contactsOf("a@test.com", "b@test.com"), newCampaign.CreatedBy)
+	campaignToSend.Started()
+	campaignToSend.Contacts[0].Sent()
+	var emailsSent []string
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		emailsSent = append(emailsSent, contact.Email)
+		return nil
+	})
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignToSend)
+
+	assert.Equal(t, []string{"b@test.com"}, emailsSent)
+}
+
+func Test_SendEmailUpdateStatus_TemporaryError_ContactIsDeferred(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	setupSendEmailTest(&campaign.DeliveryError{Code: 451, Temporary: true, Err: errors.New("451 greylisted")})
+	service.RetryPolicy = campaign.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute}
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	contact := campaignPendenting.Contacts[0]
+	assert.Equal(t, campaign.Started, campaignPendenting.Status)
+	assert.Equal(t, campaign.ContactDeferred, contact.Status)
+	assert.Equal(t, 1, contact.Attempts)
+	assert.True(t, contact.NextAttemptOn.After(time.Now().Add(50*time.Second)))
+	assert.Len(t, contact.DeliveryAttempts, 1)
+	assert.Equal(t, 451, contact.DeliveryAttempts[0].Code)
+	assert.True(t, contact.DeliveryAttempts[0].Temporary)
+}
+
+func Test_SendEmailUpdateStatus_TemporaryErrorOnLastAttempt_ContactFails(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	campaignPendenting.Contacts[0].Attempts = 2
+	setupSendEmailTest(&campaign.DeliveryError{Code: 451, Temporary: true, Err: errors.New("451 greylisted")})
+	service.RetryPolicy = campaign.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute}
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	assert.Equal(t, campaign.ContactFailed, campaignPendenting.Contacts[0].Status)
+	assert.Equal(t, 3, campaignPendenting.Contacts[0].Attempts)
+	assert.Equal(t, campaign.Fail, campaignPendenting.Status)
+}
+
+func Test_SendEmailUpdateStatus_PermanentError_ContactFailsWithoutRetry(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	setupSendEmailTest(&campaign.DeliveryError{Code: 550, Err: errors.New("550 mailbox unavailable")})
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	assert.Equal(t, campaign.ContactFailed, campaignPendenting.Contacts[0].Status)
+	assert.Equal(t, 1, campaignPendenting.Contacts[0].Attempts)
+	assert.Nil(t, campaignPendenting.Contacts[0].NextAttemptOn)
+}
+
+func Test_SendEmailUpdateStatus_DeferredContactNotDue_IsSkipped(t *testing.T) {
+	setupServiceTest()
+	campaignToSend, _ := campaign.NewCampaign(newCampaign.Name, newCampaign.Subject, newCampaign.Content, contactsOf("a@test.com", "b@test.com"), newCampaign.CreatedBy)
+	campaignToSend.Started()
+	campaignToSend.Contacts[0].Deferred(errors.New("451 greylisted"), time.Now().Add(time.Hour))
+	var emailsSent []string
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		emailsSent = append(emailsSent, contact.Email)
+		return nil
+	})
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignToSend)
+
+	assert.Equal(t, []string{"b@test.com"}, emailsSent)
+	assert.Equal(t, campaign.Started, campaignToSend.Status)
+}
+
+func Test_ClaimCampaignsToBeSent_LeaseToWorker(t *testing.T) {
+	setupServiceTest()
+	service.WorkerID = "worker-1"
+	service.LeaseDuration = time.Minute
+	repositoryMock.On("Claim", fromRequest, "worker-1", mock.MatchedBy(func(leaseUntil time.Time) bool {
+		return leaseUntil.After(time.Now().Add(50*time.Second)) && leaseUntil.Before(time.Now().Add(time.Minute+time.Second))
+	}), 10).Return([]campaign.Campaign{*campaignStarted}, nil)
+
+	campaigns, err := service.ClaimCampaignsToBeSent(requestCtx, 10)
+
+	assert.Nil(t, err)
+	assert.Len(t, campaigns, 1)
+}
+
+func Test_SendEmailUpdateStatus_LeaseLost_StopSending(t *testing.T) {
+	setupServiceTest()
+	campaignToSend, _ := campaign.NewCampaign(newCampaign.Name, newCampaign.Subject, newCampaign.Content, contactsOf("a@test.com", "b@test.com"), newCampaign.CreatedBy)
+	campaignToSend.Started()
+	campaignToSend.Claim("worker-1", time.Now().Add(time.Minute))
+	var emailsSent []string
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		emailsSent = append(emailsSent, contact.Email)
+		return nil
+	})
+	repositoryMock.On("GetDeliveries", fromRequest, mock.Anything).Return([]campaign.Delivery{}, nil)
+	repositoryMock.On("SaveDelivery", fromRequest, mock.Anything).Return(nil)
+	repositoryMock.On("RenewLease", fromRequest, mock.Anything, mock.Anything).Return(nil).Once()
+	repositoryMock.On("RenewLease", fromRequest, mock.Anything, mock.Anything).Return(campaign.ErrLeaseLost)
+	repositoryMock.On("UpdateContact", fromRequest, mock.Anything).Return(nil)
+
+	err := service.SendEmailAndUpdateStatus(requestCtx, campaignToSend)
+
+	assert.Equal(t, campaign.ErrLeaseLost, err)
+	assert.Equal(t, []string{"a@test.com"}, emailsSent)
+	repositoryMock.AssertNotCalled(t, "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_SendEmailUpdateStatus_SaveEachContactAndReleaseAsWorker(t *testing.T) {
+	setupServiceTest()
+	service.WorkerID = "worker-1"
+	campaignToSend, _ := campaign.NewCampaign(newCampaign.Name, newCampaign.Subject, newCampaign.Content, contactsOf("a@test.com", "b@test.com"), newCampaign.CreatedBy)
+	campaignToSend.Started()
+	campaignToSend.Claim("worker-1", time.Now().Add(time.Minute))
+	setupSendEmailTest(nil)
+	repositoryMock.On("GetDeliveries", fromRequest, mock.Anything).Return([]campaign.Delivery{}, nil)
+	repositoryMock.On("SaveDelivery", fromRequest, mock.Anything).Return(nil)
+	repositoryMock.On("RenewLease", fromRequest, mock.Anything, "worker-1").Return(nil)
+	repositoryMock.On("UpdateContact", fromRequest, mock.Anything).Return(nil)
+	repositoryMock.On("Release", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Done && campaignToUpdate.LeaseOwner == ""
+	}), "worker-1").Return(nil)
+
+	err := service.SendEmailAndUpdateStatus(requestCtx, campaignToSend)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertNumberOfCalls(t, "UpdateContact", 2)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_SendEmailUpdateStatus_ContactDeferred_ReleaseAsStarted(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	campaignPendenting.Claim("worker-1", time.Now().Add(time.Minute))
+	setupSendEmailTest(&campaign.DeliveryError{Code: 451, Temporary: true, Err: errors.New("451 greylisted")})
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Started
+	}), mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_SendEmailUpdateStatus_WriteLedgerBeforeAndAfterSending(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	var saved []campaign.Delivery
+	var statusWhileSending string
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		statusWhileSending = saved[len(saved)-1].Status
+		return nil
+	})
+	repositoryMock.On("GetDeliveries", fromRequest, campaignPendenting.ID).Return([]campaign.Delivery{}, nil)
+	repositoryMock.On("SaveDelivery", fromRequest, mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, *args.Get(1).(*campaign.Delivery))
+	}).Return(nil)
+	repositoryMock.On("RenewLease", fromRequest, mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("UpdateContact", fromRequest, mock.Anything).Return(nil)
+	repositoryMock.On("Release", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	contact := campaignPendenting.Contacts[0]
+	assert.Equal(t, campaign.DeliveryInFlight, statusWhileSending)
+	assert.Len(t, saved, 2)
+	assert.Equal(t, campaign.DeliveryAccepted, saved[1].Status)
+	assert.Equal(t, contact.ID, saved[1].ContactId)
+	assert.Equal(t, saved[0].MessageId, contact.MessageId)
+	assert.NotEmpty(t, contact.MessageId)
+}
+
+func Test_SendEmailUpdateStatus_ContactAcceptedInLedger_IsNotSentAgain(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	contactId := campaignPendenting.Contacts[0].ID
+	sent := false
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		sent = true
+		return nil
+	})
+	repositoryMock.On("GetDeliveries", fromRequest, campaignPendenting.ID).Return([]campaign.Delivery{
+		{CampaignId: campaignPendenting.ID, ContactId: contactId, MessageId: "<1@test.com>", Status: campaign.DeliveryAccepted},
+	}, nil)
+	repositoryMock.On("RenewLease", fromRequest, mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("UpdateContact", fromRequest, mock.Anything).Return(nil)
+	repositoryMock.On("Release", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Done
+	}), mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	assert.False(t, sent)
+	assert.Equal(t, campaign.ContactSent, campaignPendenting.Contacts[0].Status)
+	repositoryMock.AssertNotCalled(t, "SaveDelivery", mock.Anything, mock.Anything)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_SendEmailUpdateStatus_ContactInFlightInLedger_ResendWithSameMessageId(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	contactId := campaignPendenting.Contacts[0].ID
+	var messageId string
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		messageId = contact.MessageId
+		return nil
+	})
+	repositoryMock.On("GetDeliveries", fromRequest, campaignPendenting.ID).Return([]campaign.Delivery{
+		{CampaignId: campaignPendenting.ID, ContactId: contactId, MessageId: "<1@test.com>", Status: campaign.DeliveryInFlight},
+	}, nil)
+	repositoryMock.On("SaveDelivery", fromRequest, mock.Anything).Return(nil)
+	repositoryMock.On("RenewLease", fromRequest, mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("UpdateContact", fromRequest, mock.Anything).Return(nil)
+	repositoryMock.On("Release", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	assert.Equal(t, "<1@test.com>", messageId)
+}
+
+func Test_SendEmailUpdateStatus_LedgerCannotBeWritten_DoNotSend(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	sent := false
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		sent = true
+		return nil
+	})
+	repositoryMock.On("GetDeliveries", fromRequest, mock.Anything).Return([]campaign.Delivery{}, nil)
+	repositoryMock.On("SaveDelivery", fromRequest, mock.Anything).Return(errors.New("database is down"))
+	repositoryMock.On("RenewLease", fromRequest, mock.Anything, mock.Anything).Return(nil)
+
+	err := service.SendEmailAndUpdateStatus(requestCtx, campaignPendenting)
+
+	assert.EqualError(t, err, "database is down")
+	assert.False(t, sent)
+}
+
+func Test_SendEmailUpdateStatus_ContextDone_CheckpointWithoutSending(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Started()
+	campaignPendenting.Claim("worker-1", time.Now().Add(time.Minute))
+	sent := false
+	service.Mailer = campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+		sent = true
+		return nil
+	})
+	setupSendRepository()
+	repositoryMock.On("Release", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Started
+	}), mock.Anything).Return(nil)
+	ctx, cancel := context.WithCancel(requestCtx)
+	cancel()
+
+	err := service.SendEmailAndUpdateStatus(ctx, campaignPendenting)
+
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, sent)
+	repositoryMock.AssertCalled(t, "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_Schedule_CampaignIsNotPending_Err(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignStarted, nil)
+
+	err := service.Schedule(requestCtx, campaignStarted.ID, contract.ScheduleCampaignRequest{ScheduledFor: time.Now().Add(time.Hour)}, owner)
+
+	assert.True(t, errors.Is(err, campaign.ErrStatusInvalid))
+}
+
+func Test_Schedule_CampaignWasUpdated_StatusIsScheduled(t *testing.T) {
+	setupServiceTest()
+	scheduledFor := time.Now().Add(time.Hour)
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("UpdateStatus", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Scheduled && campaignToUpdate.ScheduledFor.Equal(scheduledFor)
+	})).Return(nil)
+
+	err := service.Schedule(requestCtx, campaignPendenting.ID, contract.ScheduleCampaignRequest{ScheduledFor: scheduledFor}, owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Unschedule_CampaignIsNotScheduled_Err(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+
+	err := service.Unschedule(requestCtx, campaignPendenting.ID, owner)
+
+	assert.True(t, errors.Is(err, campaign.ErrStatusInvalid))
+}
+
+func Test_Unschedule_CampaignWasUpdated_StatusIsPending(t *testing.T) {
+	setupServiceTest()
+	campaignPendenting.Schedule(time.Now().Add(time.Hour))
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("UpdateStatus", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Pending && campaignToUpdate.ScheduledFor == nil
+	})).Return(nil)
+
+	err := service.Unschedule(requestCtx, campaignPendenting.ID, owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Cancel_CampaignWasUpdated_StatusIsCanceled(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignStarted, nil)
+	repositoryMock.On("UpdateStatus", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Canceled
+	})).Return(nil)
+
+	err := service.Cancel(requestCtx, campaignStarted.ID, owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Cancel_CampaignIsDone_Err(t *testing.T) {
+	setupServiceTest()
+	campaignDone := &campaign.Campaign{ID: "1", Status: campaign.Done, CreatedBy: newCampaign.CreatedBy}
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignDone, nil)
+
+	err := service.Cancel(requestCtx, campaignDone.ID, owner)
+
+	assert.True(t, errors.Is(err, campaign.ErrStatusInvalid))
+	repositoryMock.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+func Test_Pause_CampaignWasUpdated_StatusIsPaused(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignStarted, nil)
+	repositoryMock.On("UpdateStatus", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Paused
+	})).Return(nil)
+
+	err := service.Pause(requestCtx, campaignStarted.ID, owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Resume_CampaignWasUpdated_StatusIsStarted(t *testing.T) {
+	setupServiceTest()
+	campaignPaused := &campaign.Campaign{ID: "1", Status: campaign.Paused, CreatedBy: newCampaign.CreatedBy}
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPaused, nil)
+	repositoryMock.On("UpdateStatus", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Status == campaign.Started
+	})).Return(nil)
+	repositoryMock.On("Notify", fromRequest, campaignPaused).Return(nil)
+
+	err := service.Resume(requestCtx, campaignPaused.ID, owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Resume_ErrorOnRepository_ErrInternal(t *testing.T) {
+	setupServiceTest()
+	campaignPaused := &campaign.Campaign{ID: "1", Status: campaign.Paused, CreatedBy: newCampaign.CreatedBy}
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPaused, nil)
+	repositoryMock.On("UpdateStatus", fromRequest, mock.Anything).Return(errors.New("error to update campaign"))
+
+	err := service.Resume(requestCtx, campaignPaused.ID, owner)
+
+	assert.Equal(t, internalerrors.ErrInternal.Error(), err.Error())
+}
+
+func Test_List_ReturnCampaignsAndNextCursor(t *testing.T) {
+	setupServiceTest()
+	campaigns := []campaign.Campaign{*campaignPendenting, *campaignStarted, {ID: "3"}}
+	repositoryMock.On("Get", fromRequest, mock.MatchedBy(func(filter campaign.ListFilter) bool {
+		return filter.CreatedBy == newCampaign.CreatedBy &&
+			filter.Status == campaign.Pending &&
+			filter.SortBy == campaign.SortByCreatedOn &&
+			filter.Descending &&
+			filter.Limit == 3
+	})).Return(campaigns, nil)
+
+	response, err := service.List(requestCtx, contract.ListCampaignsRequest{Status: campaign.Pending, Limit: 2, CreatedBy: newCampaign.CreatedBy}, owner)
+
+	assert.Nil(t, err)
+	assert.Len(t, response.Campaigns, 2)
+	assert.Equal(t, campaignPendenting.ID, response.Campaigns[0].ID)
+	cursor, _ := campaign.DecodeCursor(response.NextCursor)
+	assert.Equal(t, campaignStarted.ID, cursor.ID)
+}
+
+func Test_List_LastPage_NextCursorIsEmpty(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("Get", fromRequest, mock.Anything).Return([]campaign.Campaign{*campaignPendenting}, nil)
+
+	response, err := service.List(requestCtx, contract.ListCampaignsRequest{CreatedBy: newCampaign.CreatedBy}, owner)
+
+	assert.Nil(t, err)
+	assert.Len(t, response.Campaigns, 1)
+	assert.Empty(t, response.NextCursor)
+}
+
+func Test_List_CursorIsPassedToRepository(t *testing.T) {
+	setupServiceTest()
+	cursor := &campaign.Cursor{Value: "Campaign X", ID: "1"}
+	repositoryMock.On("Get", fromRequest, mock.MatchedBy(func(filter campaign.ListFilter) bool {
+		return filter.After != nil && *filter.After == *cursor && filter.SortBy == campaign.SortByName && !filter.Descending
+	})).Return([]campaign.Campaign{}, nil)
+
+	_, err := service.List(requestCtx, contract.ListCampaignsRequest{Sort: campaign.SortByName, Cursor: cursor.Encode()}, owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_List_InvalidParameters_Err(t *testing.T) {
+	setupServiceTest()
+
+	_, errSort := service.List(requestCtx, contract.ListCampaignsRequest{Sort: "status"}, owner)
+	_, errOrder := service.List(requestCtx, contract.ListCampaignsRequest{Order: "up"}, owner)
+	_, errLimit := service.List(requestCtx, contract.ListCampaignsRequest{Limit: campaign.MaxListLimit + 1}, owner)
+	_, errCursor := service.List(requestCtx, contract.ListCampaignsRequest{Cursor: "invalid"}, owner)
+
+	assert.Equal(t, "sort is invalid", errSort.Error())
+	assert.Equal(t, "order is invalid", errOrder.Error())
+	assert.Equal(t, "limit is invalid", errLimit.Error())
+	assert.Equal(t, "cursor is invalid", errCursor.Error())
+	repositoryMock.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+}
+
+func Test_List_ErrorOnRepository_ErrInternal(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("Get", fromRequest, mock.Anything).Return(nil, errors.New("error to list campaigns"))
+
+	_, err := service.List(requestCtx, contract.ListCampaignsRequest{}, owner)
+
+	assert.Equal(t, internalerrors.ErrInternal.Error(), err.Error())
+}
+
+func Test_Update_CampaignWasNotFound_ErrRecordNotFound(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+
+	err := service.Update(requestCtx, "invalid campaign", contract.UpdateCampaignRequest{}, owner)
+
+	assert.Equal(t, gorm.ErrRecordNotFound.Error(), err.Error())
+}
+
+func Test_Update_OnlyChangeFieldsInRequest(t *testing.T) {
+	setupServiceTest()
+	content := "New body!"
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("Update", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return campaignToUpdate.Name == newCampaign.Name &&
+			campaignToUpdate.Content == content &&
+			len(campaignToUpdate.Contacts) == len(newCampaign.Emails)
 	})).Return(nil)
 
-	service.SendEmailAndUpdateStatus(campaignPendenting)
+	err := service.Update(requestCtx, campaignPendenting.ID, contract.UpdateCampaignRequest{Content: &content}, owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Update_VersionIsExpected_Nil(t *testing.T) {
+	setupServiceTest()
+	content := "New body!"
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("Update", fromRequest, mock.Anything).Return(nil)
+	ctx := campaign.WithExpectedVersions(requestCtx, 7, campaignPendenting.Version)
+
+	err := service.Update(ctx, campaignPendenting.ID, contract.UpdateCampaignRequest{Content: &content}, owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Update_ReplaceContacts(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("Update", fromRequest, mock.MatchedBy(func(campaignToUpdate *campaign.Campaign) bool {
+		return len(campaignToUpdate.Contacts) == 2 &&
+			campaignToUpdate.Contacts[0].Email == "new1@test.com" &&
+			campaignToUpdate.Contacts[1].FirstName == "Ana"
+	})).Return(nil)
+
+	err := service.Update(requestCtx, campaignPendenting.ID, contract.UpdateCampaignRequest{
+		Emails:   []string{"new1@test.com"},
+		Contacts: []contract.ContactRequest{{Email: "new2@test.com", FirstName: "Ana"}},
+	}, owner)
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_Update_RequestIsNotValid_Err(t *testing.T) {
+	setupServiceTest()
+	name := "x"
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+
+	err := service.Update(requestCtx, campaignPendenting.ID, contract.UpdateCampaignRequest{Name: &name}, owner)
+
+	assert.Equal(t, "name is required with min 5", err.Error())
+	repositoryMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func Test_Update_CampaignIsNotPending_Err(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignStarted, nil)
+
+	err := service.Update(requestCtx, campaignStarted.ID, contract.UpdateCampaignRequest{}, owner)
+
+	assert.True(t, errors.Is(err, campaign.ErrStatusInvalid))
+}
+
+func Test_Update_ErrorOnRepository_ErrInternal(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+	repositoryMock.On("Update", fromRequest, mock.Anything).Return(errors.New("error to update campaign"))
+
+	err := service.Update(requestCtx, campaignPendenting.ID, contract.UpdateCampaignRequest{}, owner)
+
+	assert.Equal(t, internalerrors.ErrInternal.Error(), err.Error())
+}
+
+func Test_GetById_CallerIsNotOwner_ErrForbidden(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+
+	_, err := service.GetBy(requestCtx, campaignPendenting.ID, campaign.Caller{Email: "other@test.com"})
+
+	assert.True(t, errors.Is(err, internalerrors.ErrForbidden))
+}
+
+func Test_GetById_CallerIsAdmin_CampaignSaved(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+
+	campaignReturned, err := service.GetBy(requestCtx, campaignPendenting.ID, campaign.Caller{Email: "admin@test.com", Admin: true})
 
+	assert.Nil(t, err)
+	assert.Equal(t, campaignPendenting.ID, campaignReturned.ID)
+}
+
+func Test_Delete_CallerIsNotOwner_ErrForbidden(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+
+	err := service.Delete(requestCtx, campaignPendenting.ID, campaign.Caller{Email: "other@test.com"})
+
+	assert.True(t, errors.Is(err, internalerrors.ErrForbidden))
+	repositoryMock.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func Test_Start_CallerIsNotOwner_ErrForbidden(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("GetBy", fromRequest, mock.Anything).Return(campaignPendenting, nil)
+
+	err := service.Start(requestCtx, campaignPendenting.ID, campaign.Caller{Email: "other@test.com"})
+
+	assert.True(t, errors.Is(err, internalerrors.ErrForbidden))
+	repositoryMock.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+func Test_List_CallerIsNotAdmin_OnlyOwnCampaigns(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("Get", fromRequest, mock.MatchedBy(func(filter campaign.ListFilter) bool {
+		return filter.CreatedBy == owner.Email
+	})).Return([]campaign.Campaign{}, nil)
+
+	_, err := service.List(requestCtx, contract.ListCampaignsRequest{CreatedBy: "other@test.com"}, owner)
+
+	assert.Nil(t, err)
 	repositoryMock.AssertExpectations(t)
 }
+
+func Test_List_CallerIsAdmin_FilterByCreator(t *testing.T) {
+	setupServiceTest()
+	repositoryMock.On("Get", fromRequest, mock.MatchedBy(func(filter campaign.ListFilter) bool {
+		return filter.CreatedBy == "other@test.com"
+	})).Return([]campaign.Campaign{}, nil)
+
+	_, err := service.List(requestCtx, contract.ListCampaignsRequest{CreatedBy: "other@test.com"}, campaign.Caller{Email: "admin@test.com", Admin: true})
+
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func Test_SendEmailAndUpdateStatus_MemoryRepository_CampaignIsDone(t *testing.T) {
+	sent := []string{}
+	memoryService := campaign.ServiceImp{
+		Repository: &memory.CampaignRepository{},
+		WorkerID:   "worker-1",
+		Mailer: campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+			sent = append(sent, contact.Email)
+			return nil
+		}),
+	}
+	id, _ := memoryService.Create(requestCtx, newCampaign)
+	memoryService.Start(requestCtx, id, owner)
+
+	claimed, err := memoryService.ClaimCampaignsToBeSent(requestCtx, 10)
+	assert.Nil(t, err)
+	assert.Len(t, claimed, 1)
+	err = memoryService.SendEmailAndUpdateStatus(requestCtx, &claimed[0])
+
+	assert.Nil(t, err)
+	assert.Equal(t, newCampaign.Emails, sent)
+	campaignSaved, _ := memoryService.GetBy(requestCtx, id, owner)
+	assert.Equal(t, campaign.Done, campaignSaved.Status)
+}
+
+func Test_SendEmailAndUpdateStatus_ContextDoneDuringSend_ResultIsSaved(t *testing.T) {
+	ctx, cancel := context.WithCancel(requestCtx)
+	memoryService := campaign.ServiceImp{
+		Repository: &memory.CampaignRepository{},
+		WorkerID:   "worker-1",
+		Mailer: campaign.MailerFunc(func(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+			cancel()
+			return nil
+		}),
+	}
+	id, _ := memoryService.Create(requestCtx, newCampaign)
+	memoryService.Start(requestCtx, id, owner)
+	claimed, _ := memoryService.ClaimCampaignsToBeSent(requestCtx, 10)
+
+	err := memoryService.SendEmailAndUpdateStatus(ctx, &claimed[0])
+
+	assert.Nil(t, err)
+	campaignSaved, _ := memoryService.GetBy(requestCtx, id, owner)
+	assert.Equal(t, campaign.Done, campaignSaved.Status)
+}
@@ -0,0 +1,62 @@
+package campaign
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrStatusInvalid = errors.New("Campaign status invalid")
+
+// ErrAlreadyDispatched is returned when a campaign can no longer be taken
+// back because some of its messages were handed to the mail server.
+var ErrAlreadyDispatched = fmt.Errorf("%w: messages were already dispatched", ErrStatusInvalid)
+
+// ErrNotPending is returned when a campaign that is no longer pending is
+// asked to be updated.
+var ErrNotPending = fmt.Errorf("%w: only pending campaigns can be updated", ErrStatusInvalid)
+
+// ErrLeaseLost is returned when a worker no longer holds the lease of the
+// campaign it is sending, because the lease expired and another worker
+// claimed it or because the campaign was paused or canceled.
+var ErrLeaseLost = errors.New("Campaign lease lost")
+
+// StatusTransitionError is returned when a campaign is asked to move to a
+// status that is not reachable from its current one.
+type StatusTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return ErrStatusInvalid.Error() + ": cannot change from " + e.From + " to " + e.To
+}
+
+func (e *StatusTransitionError) Unwrap() error {
+	return ErrStatusInvalid
+}
+
+// transitions lists, for each status, the statuses a campaign may move to.
+// Done, Canceled, Deleted and Fail are final.
+var transitions = map[string][]string{
+	Pending:   {Scheduled, Started, Canceled, Deleted},
+	Scheduled: {Pending, Scheduled, Sending, Paused, Canceled, Done, Fail},
+	Started:   {Pending, Sending, Paused, Canceled, Done, Fail},
+	Sending:   {Started, Paused, Canceled, Done, Fail},
+	Paused:    {Scheduled, Started, Canceled},
+}
+
+func CanChangeStatus(from string, to string) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Campaign) checkStatusChange(to string) error {
+	if !CanChangeStatus(c.Status, to) {
+		return &StatusTransitionError{From: c.Status, To: to}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package campaign
+
+import (
+	"bytes"
+	internalerrors "emailgo/internal/internal-errors"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// templateData exposes the contact to the subject and content templates.
+// Custom fields are available by name, e.g. {{.company}}, while Email,
+// FirstName and LastName always come from the contact itself.
+func (c *Contact) templateData() map[string]string {
+	data := make(map[string]string, len(c.Fields)+3)
+	for key, value := range c.Fields {
+		data[key] = value
+	}
+	data["Email"] = c.Email
+	data["FirstName"] = c.FirstName
+	data["LastName"] = c.LastName
+	return data
+}
+
+func (c *Campaign) subjectTemplate() string {
+	if c.Subject == "" {
+		return c.Name
+	}
+	return c.Subject
+}
+
+func invalidTemplate(field string, err error) error {
+	return internalerrors.NewFieldError(field, "template", err.Error(), field+" has an invalid template: "+err.Error())
+}
+
+func (c *Campaign) parseTemplates() (*texttemplate.Template, *htmltemplate.Template, error) {
+	subject, err := texttemplate.New("subject").Option("missingkey=error").Parse(c.subjectTemplate())
+	if err != nil {
+		return nil, nil, invalidTemplate("subject", err)
+	}
+
+	content, err := htmltemplate.New("content").Option("missingkey=error").Parse(c.Content)
+	if err != nil {
+		return nil, nil, invalidTemplate("content", err)
+	}
+
+	return subject, content, nil
+}
+
+func executeTemplates(subject *texttemplate.Template, content *htmltemplate.Template, contact *Contact) (string, string, error) {
+	data := contact.templateData()
+
+	var subjectBuffer bytes.Buffer
+	if err := subject.Execute(&subjectBuffer, data); err != nil {
+		return "", "", invalidTemplate("subject", err)
+	}
+
+	var contentBuffer bytes.Buffer
+	if err := content.Execute(&contentBuffer, data); err != nil {
+		return "", "", invalidTemplate("content", err)
+	}
+
+	return subjectBuffer.String(), contentBuffer.String(), nil
+}
+
+// Render returns the subject and the content personalized for the contact.
+func (c *Campaign) Render(contact *Contact) (string, string, error) {
+	subject, content, err := c.parseTemplates()
+	if err != nil {
+		return "", "", err
+	}
+	return executeTemplates(subject, content, contact)
+}
+
+// validateTemplates renders the templates for every contact, so a template
+// that does not parse or uses a field some contact lacks is rejected when
+// the campaign is created instead of when it is sent.
+func (c *Campaign) validateTemplates() error {
+	subject, content, err := c.parseTemplates()
+	if err != nil {
+		return err
+	}
+
+	for index := range c.Contacts {
+		if _, _, err := executeTemplates(subject, content, &c.Contacts[index]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package endpoints
+
+import (
+	"context"
+	"emailgo/internal/infrastructure/credential"
+)
+
+// ValidateApiKey authenticates an API key and returns its owner, whose
+// permissions are the key scopes.
+func (h *Handler) ValidateApiKey(key string, ctx context.Context) (*credential.Principal, error) {
+	apiKey, err := h.ApiKeyService.Authenticate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &credential.Principal{
+		Email:    apiKey.Owner,
+		ApiKeyID: apiKey.ID,
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
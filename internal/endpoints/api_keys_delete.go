@@ -0,0 +1,19 @@
+package endpoints
+
+import (
+	internalerrors "emailgo/internal/internal-errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) ApiKeyDelete(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	principal := principalFrom(r)
+	if principal == nil || principal.IsApiKey() {
+		return nil, 0, internalerrors.ErrForbidden
+	}
+
+	id := chi.URLParam(r, "id")
+	err := h.ApiKeyService.Revoke(r.Context(), id, principal.Email)
+	return nil, 200, err
+}
@@ -0,0 +1,52 @@
+package endpoints
+
+import (
+	"emailgo/internal/infrastructure/credential"
+	internalerrors "emailgo/internal/internal-errors"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_ApiKeyDelete_200(t *testing.T) {
+	setupTest()
+	apiKeyService.On("Revoke", "xpto", createdByExpected).Return(nil)
+
+	req, rr := newHttpTest("DELETE", "/", nil)
+	req = addParameter(req, "id", "xpto")
+	req = addPrincipal(req, editor)
+
+	_, status, err := handler.ApiKeyDelete(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+	apiKeyService.AssertExpectations(t)
+}
+
+func Test_ApiKeyDelete_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	apiKeyService.On("Revoke", mock.Anything, mock.Anything).Return(errExpected)
+
+	req, rr := newHttpTest("DELETE", "/", nil)
+	req = addPrincipal(req, editor)
+
+	_, _, err := handler.ApiKeyDelete(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
+
+func Test_ApiKeyDelete_AuthenticatedWithApiKey_ErrForbidden(t *testing.T) {
+	setupTest()
+
+	req, rr := newHttpTest("DELETE", "/", nil)
+	req = addParameter(req, "id", "xpto")
+	req = addPrincipal(req, &credential.Principal{Email: createdByExpected, ApiKeyID: "1", Scopes: []string{"read", "write"}})
+
+	_, _, err := handler.ApiKeyDelete(rr, req)
+
+	assert.Equal(t, internalerrors.ErrForbidden, err)
+	apiKeyService.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+}
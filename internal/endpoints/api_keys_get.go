@@ -0,0 +1,16 @@
+package endpoints
+
+import (
+	internalerrors "emailgo/internal/internal-errors"
+	"net/http"
+)
+
+func (h *Handler) ApiKeyList(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	principal := principalFrom(r)
+	if principal == nil || principal.IsApiKey() {
+		return nil, 0, internalerrors.ErrForbidden
+	}
+
+	apiKeys, err := h.ApiKeyService.List(r.Context(), principal.Email)
+	return apiKeys, 200, err
+}
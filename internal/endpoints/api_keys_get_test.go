@@ -0,0 +1,52 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	"emailgo/internal/infrastructure/credential"
+	internalerrors "emailgo/internal/internal-errors"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_ApiKeyList_200(t *testing.T) {
+	setupTest()
+	responseExpected := []contract.ApiKeyResponse{{ID: "1", Name: "batch jobs"}}
+	apiKeyService.On("List", createdByExpected).Return(responseExpected, nil)
+
+	req, rr := newHttpTest("GET", "/", nil)
+	req = addPrincipal(req, editor)
+
+	response, status, err := handler.ApiKeyList(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+	assert.Equal(t, responseExpected, response)
+}
+
+func Test_ApiKeyList_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	apiKeyService.On("List", mock.Anything).Return(nil, errExpected)
+
+	req, rr := newHttpTest("GET", "/", nil)
+	req = addPrincipal(req, editor)
+
+	_, _, err := handler.ApiKeyList(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
+
+func Test_ApiKeyList_AuthenticatedWithApiKey_ErrForbidden(t *testing.T) {
+	setupTest()
+
+	req, rr := newHttpTest("GET", "/", nil)
+	req = addPrincipal(req, &credential.Principal{Email: createdByExpected, ApiKeyID: "1", Scopes: []string{"read"}})
+
+	_, _, err := handler.ApiKeyList(rr, req)
+
+	assert.Equal(t, internalerrors.ErrForbidden, err)
+	apiKeyService.AssertNotCalled(t, "List", mock.Anything)
+}
@@ -0,0 +1,29 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	internalerrors "emailgo/internal/internal-errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+func (h *Handler) ApiKeyPost(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	principal := principalFrom(r)
+	if principal == nil || principal.IsApiKey() {
+		return nil, 0, internalerrors.ErrForbidden
+	}
+
+	var request contract.NewApiKeyRequest
+	render.DecodeJSON(r.Body, &request)
+
+	for _, scope := range request.Scopes {
+		if !Roles.Allows(principal, Permission(scope)) {
+			return nil, 0, internalerrors.NewFieldError("scope", "granted", scope, "scope "+scope+" is not granted to the user")
+		}
+	}
+
+	request.Owner = principal.Email
+	apiKey, err := h.ApiKeyService.Create(r.Context(), request)
+	return apiKey, 201, err
+}
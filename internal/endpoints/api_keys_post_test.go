@@ -0,0 +1,55 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	"emailgo/internal/infrastructure/credential"
+	internalerrors "emailgo/internal/internal-errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var editor = &credential.Principal{Email: createdByExpected, RealmRoles: []string{"editor"}}
+
+func Test_ApiKeyPost_201(t *testing.T) {
+	setupTest()
+	Roles = DefaultRoleMapping()
+	responseExpected := &contract.ApiKeyCreatedResponse{Key: "egk_1_secret"}
+	apiKeyService.On("Create", mock.MatchedBy(func(request contract.NewApiKeyRequest) bool {
+		return request.Name == "batch jobs" && request.Owner == createdByExpected
+	})).Return(responseExpected, nil)
+
+	req, rr := newHttpTest("POST", "/", contract.NewApiKeyRequest{Name: "batch jobs", Scopes: []string{"read", "write"}})
+	req = addPrincipal(req, editor)
+
+	response, status, err := handler.ApiKeyPost(rr, req)
+
+	assert.Equal(t, 201, status)
+	assert.Nil(t, err)
+	assert.Equal(t, responseExpected, response)
+}
+
+func Test_ApiKeyPost_ScopeNotGrantedToUser_Err(t *testing.T) {
+	setupTest()
+	Roles = DefaultRoleMapping()
+
+	req, rr := newHttpTest("POST", "/", contract.NewApiKeyRequest{Name: "batch jobs", Scopes: []string{"send"}})
+	req = addPrincipal(req, editor)
+
+	_, _, err := handler.ApiKeyPost(rr, req)
+
+	assert.Equal(t, "scope send is not granted to the user", err.Error())
+	apiKeyService.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func Test_ApiKeyPost_AuthenticatedWithApiKey_ErrForbidden(t *testing.T) {
+	setupTest()
+
+	req, rr := newHttpTest("POST", "/", contract.NewApiKeyRequest{Name: "batch jobs", Scopes: []string{"read"}})
+	req = addPrincipal(req, &credential.Principal{Email: createdByExpected, ApiKeyID: "1", Scopes: []string{"read", "write"}})
+
+	_, _, err := handler.ApiKeyPost(rr, req)
+
+	assert.Equal(t, internalerrors.ErrForbidden, err)
+}
@@ -2,35 +2,68 @@ package endpoints
 
 import (
 	"context"
+	"emailgo/internal/domain/campaign"
 	"emailgo/internal/infrastructure/credential"
 	"net/http"
-
-	"github.com/go-chi/render"
 )
 
-type ValidateTokenFunc func(token string, ctx context.Context) (string, error)
+type ValidateTokenFunc func(token string, ctx context.Context) (*credential.Principal, error)
+
+type ValidateApiKeyFunc func(key string, ctx context.Context) (*credential.Principal, error)
+
+// ValidateToken is set at startup with the validator of the configured
+// provider.
+var ValidateToken ValidateTokenFunc
 
-var ValidateToken ValidateTokenFunc = credential.ValidateToken
+// ValidateApiKey is set at startup with Handler.ValidateApiKey.
+var ValidateApiKey ValidateApiKeyFunc
 
+const ApiKeyHeader = "X-API-Key"
+
+// Auth accepts either a bearer token in Authorization or an API key in
+// X-API-Key.
 func Auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		tokenString := r.Header.Get("Authorization")
+		apiKey := r.Header.Get(ApiKeyHeader)
+
+		translator := translatorFor(r)
 
-		if tokenString == "" {
-			render.Status(r, 401)
-			render.JSON(w, r, map[string]string{"error": "request does not contain an authorization header"})
+		if tokenString == "" && apiKey == "" {
+			writeProblem(w, translator, newProblem(http.StatusUnauthorized, CodeUnauthorized, message(translator, messageMissingAuthorization)))
 			return
 		}
 
-		email, err := ValidateToken(tokenString, r.Context())
+		var principal *credential.Principal
+		var err error
+		if tokenString != "" {
+			principal, err = ValidateToken(tokenString, r.Context())
+		} else {
+			principal, err = ValidateApiKey(apiKey, r.Context())
+		}
 		if err != nil {
-			render.Status(r, 401)
-			render.JSON(w, r, map[string]string{"error": "invalid token"})
+			key := messageInvalidToken
+			if tokenString == "" {
+				key = messageInvalidApiKey
+			}
+			writeProblem(w, translator, newProblem(http.StatusUnauthorized, CodeUnauthorized, message(translator, key)))
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), "email", email)
+		ctx := context.WithValue(r.Context(), "email", principal.Email)
+		ctx = context.WithValue(ctx, "principal", principal)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+func principalFrom(r *http.Request) *credential.Principal {
+	principal, _ := r.Context().Value("principal").(*credential.Principal)
+	return principal
+}
+
+// callerFrom returns the user the Auth middleware put into the request.
+func callerFrom(r *http.Request) campaign.Caller {
+	email, _ := r.Context().Value("email").(string)
+	return campaign.Caller{Email: email, Admin: Roles.Allows(principalFrom(r), PermissionAdmin)}
+}
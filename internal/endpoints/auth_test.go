@@ -2,6 +2,9 @@ package endpoints
 
 import (
 	"context"
+	"emailgo/internal/contract"
+	"emailgo/internal/domain/campaign"
+	"emailgo/internal/infrastructure/credential"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -29,8 +32,8 @@ func Test_Auth_WhenAuthorizationIsInvalid_ReturnError(t *testing.T) {
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("next handler should not be called")
 	})
-	ValidateToken = func(token string, ctx context.Context) (string, error) {
-		return "", errors.New("invalid token")
+	ValidateToken = func(token string, ctx context.Context) (*credential.Principal, error) {
+		return nil, errors.New("invalid token")
 	}
 
 	handlerFunc := Auth(nextHandler)
@@ -50,8 +53,8 @@ func Test_Auth_WhenAuthorizationIsInvalid_CallNextHandler(t *testing.T) {
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		email = r.Context().Value("email").(string)
 	})
-	ValidateToken = func(token string, ctx context.Context) (string, error) {
-		return emailExpected, nil
+	ValidateToken = func(token string, ctx context.Context) (*credential.Principal, error) {
+		return &credential.Principal{Email: emailExpected}, nil
 	}
 
 	handlerFunc := Auth(nextHandler)
@@ -64,3 +67,85 @@ func Test_Auth_WhenAuthorizationIsInvalid_CallNextHandler(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.Code)
 	assert.Equal(t, emailExpected, email)
 }
+
+func Test_CallerFrom_WhenUserHasAdminRole_CallerIsAdmin(t *testing.T) {
+	var caller campaign.Caller
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller = callerFrom(r)
+	})
+	ValidateToken = func(token string, ctx context.Context) (*credential.Principal, error) {
+		return &credential.Principal{Email: "admin@teste.com", RealmRoles: []string{"offline_access", "admin"}}, nil
+	}
+
+	handlerFunc := Auth(nextHandler)
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Add("Authorization", "Bearer valid token")
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	assert.Equal(t, campaign.Caller{Email: "admin@teste.com", Admin: true}, caller)
+}
+
+func Test_CallerFrom_WhenUserHasNoAdminRole_CallerIsNotAdmin(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req = addContext(req, "email", "teste@teste.com")
+
+	caller := callerFrom(req)
+
+	assert.Equal(t, campaign.Caller{Email: "teste@teste.com"}, caller)
+}
+
+func Test_Auth_WhenApiKeyIsValid_CallNextHandlerWithKeyOwner(t *testing.T) {
+	var principal *credential.Principal
+	var email string
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = principalFrom(r)
+		email = r.Context().Value("email").(string)
+	})
+	ValidateApiKey = func(key string, ctx context.Context) (*credential.Principal, error) {
+		assert.Equal(t, "egk_1_secret", key)
+		return &credential.Principal{Email: "owner@teste.com", ApiKeyID: "1", Scopes: []string{"write"}}, nil
+	}
+
+	handlerFunc := Auth(nextHandler)
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Add(ApiKeyHeader, "egk_1_secret")
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "owner@teste.com", email)
+	assert.True(t, Roles.Allows(principal, PermissionWrite))
+	assert.False(t, Roles.Allows(principal, PermissionSend))
+}
+
+func Test_Auth_WhenApiKeyIsInvalid_ReturnError(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called")
+	})
+	ValidateApiKey = func(key string, ctx context.Context) (*credential.Principal, error) {
+		return nil, errors.New("invalid api key")
+	}
+
+	handlerFunc := Auth(nextHandler)
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Add(ApiKeyHeader, "egk_1_wrong")
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Contains(t, res.Body.String(), "invalid api key")
+}
+
+func Test_ValidateApiKey_ReturnPrincipalWithScopes(t *testing.T) {
+	setupTest()
+	apiKeyService.On("Authenticate", "egk_1_secret").Return(&contract.ApiKeyResponse{ID: "1", Owner: "owner@teste.com", Scopes: []string{"read"}}, nil)
+
+	principal, err := handler.ValidateApiKey("egk_1_secret", context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, &credential.Principal{Email: "owner@teste.com", ApiKeyID: "1", Scopes: []string{"read"}}, principal)
+}
@@ -0,0 +1,97 @@
+package endpoints
+
+import (
+	"emailgo/internal/infrastructure/credential"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type Permission string
+
+const (
+	PermissionRead  Permission = "read"
+	PermissionWrite Permission = "write"
+	PermissionSend  Permission = "send"
+	PermissionAdmin Permission = "admin"
+)
+
+// RoleMapping lists, for each permission, the token roles that grant it.
+type RoleMapping map[Permission][]string
+
+// Roles is the mapping used by Authorize and by the admin override of the
+// campaign service.
+var Roles = DefaultRoleMapping()
+
+func DefaultRoleMapping() RoleMapping {
+	return RoleMapping{
+		PermissionRead:  {"viewer", "editor", "sender", "admin"},
+		PermissionWrite: {"editor", "admin"},
+		PermissionSend:  {"sender", "admin"},
+		PermissionAdmin: {"admin"},
+	}
+}
+
+// ParseRoleMapping reads a mapping written as
+// "read=viewer,editor;write=editor;send=sender;admin=admin".
+// Permissions left out keep the roles of the default mapping.
+func ParseRoleMapping(value string) (RoleMapping, error) {
+	mapping := DefaultRoleMapping()
+
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		permission, roles, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, errors.New("role mapping entry is invalid: " + entry)
+		}
+		if _, known := mapping[Permission(permission)]; !known {
+			return nil, errors.New("role mapping permission is invalid: " + permission)
+		}
+
+		mapping[Permission(permission)] = nil
+		for _, role := range strings.Split(roles, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				mapping[Permission(permission)] = append(mapping[Permission(permission)], role)
+			}
+		}
+	}
+
+	return mapping, nil
+}
+
+func (m RoleMapping) Allows(principal *credential.Principal, permission Permission) bool {
+	if principal == nil {
+		return false
+	}
+	if principal.IsApiKey() {
+		return slices.Contains(principal.Scopes, string(permission))
+	}
+	for _, role := range m[permission] {
+		if principal.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize only lets through requests whose principal has a role granting
+// the permission. It must run after Auth.
+func Authorize(permission Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !Roles.Allows(principalFrom(r), permission) {
+				translator := translatorFor(r)
+				detail := message(translator, messageMissingPermission, string(permission))
+				writeProblem(w, translator, newProblem(http.StatusForbidden, CodeForbidden, detail))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package endpoints
+
+import (
+	"context"
+	"emailgo/internal/infrastructure/credential"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newAuthorizedRequest(principal *credential.Principal) *http.Request {
+	req, _ := http.NewRequest("GET", "/", nil)
+	return req.WithContext(context.WithValue(req.Context(), "principal", principal))
+}
+
+func Test_Authorize_WhenRealmRoleGrantsPermission_CallNextHandler(t *testing.T) {
+	Roles = DefaultRoleMapping()
+	called := false
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	handlerFunc := Authorize(PermissionRead)(nextHandler)
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, newAuthorizedRequest(&credential.Principal{RealmRoles: []string{"viewer"}}))
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
+
+func Test_Authorize_WhenClientRoleGrantsPermission_CallNextHandler(t *testing.T) {
+	Roles = DefaultRoleMapping()
+	called := false
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	handlerFunc := Authorize(PermissionSend)(nextHandler)
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, newAuthorizedRequest(&credential.Principal{ClientRoles: []string{"sender"}}))
+
+	assert.True(t, called)
+}
+
+func Test_Authorize_WhenNoRoleGrantsPermission_ReturnForbidden(t *testing.T) {
+	Roles = DefaultRoleMapping()
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called")
+	})
+
+	handlerFunc := Authorize(PermissionWrite)(nextHandler)
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, newAuthorizedRequest(&credential.Principal{RealmRoles: []string{"viewer"}}))
+
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Contains(t, res.Body.String(), "user does not have the write permission")
+}
+
+func Test_Authorize_WhenPrincipalIsMissing_ReturnForbidden(t *testing.T) {
+	Roles = DefaultRoleMapping()
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called")
+	})
+
+	handlerFunc := Authorize(PermissionRead)(nextHandler)
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Code)
+}
+
+func Test_ParseRoleMapping_OverrideOnlyGivenPermissions(t *testing.T) {
+	mapping, err := ParseRoleMapping("read=reader, auditor;send=mailer")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"reader", "auditor"}, mapping[PermissionRead])
+	assert.Equal(t, []string{"mailer"}, mapping[PermissionSend])
+	assert.Equal(t, DefaultRoleMapping()[PermissionWrite], mapping[PermissionWrite])
+}
+
+func Test_ParseRoleMapping_Empty_DefaultMapping(t *testing.T) {
+	mapping, err := ParseRoleMapping("")
+
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultRoleMapping(), mapping)
+}
+
+func Test_ParseRoleMapping_Invalid_Err(t *testing.T) {
+	_, errEntry := ParseRoleMapping("read")
+	_, errPermission := ParseRoleMapping("delete=editor")
+
+	assert.Equal(t, "role mapping entry is invalid: read", errEntry.Error())
+	assert.Equal(t, "role mapping permission is invalid: delete", errPermission.Error())
+}
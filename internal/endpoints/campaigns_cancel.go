@@ -0,0 +1,13 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) CampaignCancel(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	id := chi.URLParam(r, "id")
+	err := h.CampaignService.Cancel(r.Context(), id, callerFrom(r))
+	return nil, 200, err
+}
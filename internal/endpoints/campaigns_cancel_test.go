@@ -0,0 +1,38 @@
+package endpoints
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CampaignCancel_200(t *testing.T) {
+	setupTest()
+	campaignId := "xpto"
+
+	service.On("Cancel", mock.MatchedBy(func(id string) bool {
+		return id == campaignId
+	}), mock.Anything).Return(nil)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+	req = addParameter(req, "id", campaignId)
+
+	_, status, err := handler.CampaignCancel(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+}
+
+func Test_CampaignCancel_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	service.On("Cancel", mock.Anything, mock.Anything).Return(errExpected)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+
+	_, _, err := handler.CampaignCancel(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
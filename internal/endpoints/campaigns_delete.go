@@ -8,6 +8,6 @@ import (
 
 func (h *Handler) CampaignDelete(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
 	id := chi.URLParam(r, "id")
-	err := h.CampaignService.Delete(id)
+	err := h.CampaignService.Delete(r.Context(), id, callerFrom(r))
 	return nil, 200, err
 }
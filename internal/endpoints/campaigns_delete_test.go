@@ -1,6 +1,7 @@
 package endpoints
 
 import (
+	"emailgo/internal/domain/campaign"
 	"errors"
 	"testing"
 
@@ -13,7 +14,7 @@ func Test_CampaignsDelete_200(t *testing.T) {
 	campaignId := "xpto"
 	service.On("Delete", mock.MatchedBy(func(id string) bool {
 		return id == campaignId
-	})).Return(nil)
+	}), mock.Anything).Return(nil)
 	req, rr := newHttpTest("PATCH", "/", nil)
 	req = addParameter(req, "id", campaignId)
 
@@ -23,10 +24,22 @@ func Test_CampaignsDelete_200(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func Test_CampaignsDelete_PassCallerToService(t *testing.T) {
+	setupTest()
+	service.On("Delete", "xpto", campaign.Caller{Email: createdByExpected}).Return(nil)
+	req, rr := newHttpTest("PATCH", "/", nil)
+	req = addParameter(req, "id", "xpto")
+	req = addContext(req, "email", createdByExpected)
+
+	handler.CampaignDelete(rr, req)
+
+	service.AssertExpectations(t)
+}
+
 func Test_CampaignsDelete_Err(t *testing.T) {
 	setupTest()
 	errExpected := errors.New("something wrong")
-	service.On("Delete", mock.Anything).Return(errExpected)
+	service.On("Delete", mock.Anything, mock.Anything).Return(errExpected)
 	req, rr := newHttpTest("PATCH", "/", nil)
 
 	_, _, err := handler.CampaignDelete(rr, req)
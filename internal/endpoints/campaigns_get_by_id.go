@@ -8,9 +8,12 @@ import (
 
 func (h *Handler) CampaignGetById(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
 	id := chi.URLParam(r, "id")
-	campaign, err := h.CampaignService.GetBy(id)
+	campaign, err := h.CampaignService.GetBy(r.Context(), id, callerFrom(r))
 	if err == nil && campaign == nil {
 		return nil, http.StatusNotFound, err
 	}
+	if err == nil {
+		w.Header().Set("ETag", entityTag(campaign.Version))
+	}
 	return campaign, 200, err
 }
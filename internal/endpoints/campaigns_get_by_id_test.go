@@ -20,10 +20,11 @@ func Test_CampaignsGetById_should_return_campaign(t *testing.T) {
 		Name:    "Test",
 		Content: "Hi",
 		Status:  "Pending",
+		Version: 3,
 	}
 
 	service := new(internalmock.CampaignServiceMock)
-	service.On("GetBy", mock.Anything).Return(&campaign, nil)
+	service.On("GetBy", mock.Anything, mock.Anything).Return(&campaign, nil)
 
 	handler := Handler{CampaignService: service}
 
@@ -35,6 +36,7 @@ func Test_CampaignsGetById_should_return_campaign(t *testing.T) {
 	assert.Equal(200, status)
 	assert.Equal(campaign.ID, response.(*contract.CampaignResponse).ID)
 	assert.Equal(campaign.Name, response.(*contract.CampaignResponse).Name)
+	assert.Equal(`"3"`, rr.Header().Get("ETag"))
 }
 
 func Test_CampaignsGetById_should_return_error_when_something_wrong(t *testing.T) {
@@ -42,7 +44,7 @@ func Test_CampaignsGetById_should_return_error_when_something_wrong(t *testing.T
 
 	service := new(internalmock.CampaignServiceMock)
 	errExpected := errors.New("something wrong")
-	service.On("GetBy", mock.Anything).Return(nil, errExpected)
+	service.On("GetBy", mock.Anything, mock.Anything).Return(nil, errExpected)
 
 	handler := Handler{CampaignService: service}
 
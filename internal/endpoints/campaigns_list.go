@@ -0,0 +1,48 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	internalerrors "emailgo/internal/internal-errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (h *Handler) CampaignList(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	query := r.URL.Query()
+	request := contract.ListCampaignsRequest{
+		Status:    query.Get("status"),
+		Name:      query.Get("name"),
+		Sort:      query.Get("sort"),
+		Order:     query.Get("order"),
+		Cursor:    query.Get("cursor"),
+		CreatedBy: query.Get("createdBy"),
+	}
+
+	var err error
+	if request.CreatedFrom, err = parseTimeQuery(query.Get("createdFrom"), "createdfrom"); err != nil {
+		return nil, 0, err
+	}
+	if request.CreatedTo, err = parseTimeQuery(query.Get("createdTo"), "createdto"); err != nil {
+		return nil, 0, err
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if request.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, 0, internalerrors.NewFieldError("limit", "invalid", "", "limit is invalid")
+		}
+	}
+
+	campaigns, err := h.CampaignService.List(r.Context(), request, callerFrom(r))
+	return campaigns, 200, err
+}
+
+func parseTimeQuery(value string, field string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, internalerrors.NewFieldError(field, "invalid", "", field+" is invalid")
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,77 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	"emailgo/internal/domain/campaign"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CampaignList_200(t *testing.T) {
+	setupTest()
+	responseExpected := &contract.CampaignListResponse{
+		Campaigns:  []contract.CampaignResponse{{ID: "343", Name: "Test"}},
+		NextCursor: "next",
+	}
+	createdFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.On("List", mock.MatchedBy(func(request contract.ListCampaignsRequest) bool {
+		return request.Status == "Pending" &&
+			request.Name == "promo" &&
+			request.CreatedFrom.Equal(createdFrom) &&
+			request.CreatedTo == nil &&
+			request.Sort == "name" &&
+			request.Order == "asc" &&
+			request.Cursor == "abc" &&
+			request.Limit == 10 &&
+			request.CreatedBy == "other@teste.com"
+	}), campaign.Caller{Email: createdByExpected}).Return(responseExpected, nil)
+
+	req, rr := newHttpTest("GET", "/?status=Pending&name=promo&createdFrom=2024-01-01T00:00:00Z&sort=name&order=asc&cursor=abc&limit=10&createdBy=other@teste.com", nil)
+	req = addContext(req, "email", createdByExpected)
+
+	response, status, err := handler.CampaignList(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+	assert.Equal(t, responseExpected, response)
+}
+
+func Test_CampaignList_InvalidDate_Err(t *testing.T) {
+	setupTest()
+
+	req, rr := newHttpTest("GET", "/?createdTo=yesterday", nil)
+	req = addContext(req, "email", createdByExpected)
+
+	_, _, err := handler.CampaignList(rr, req)
+
+	assert.Equal(t, "createdto is invalid", err.Error())
+	service.AssertNotCalled(t, "List", mock.Anything)
+}
+
+func Test_CampaignList_InvalidLimit_Err(t *testing.T) {
+	setupTest()
+
+	req, rr := newHttpTest("GET", "/?limit=ten", nil)
+	req = addContext(req, "email", createdByExpected)
+
+	_, _, err := handler.CampaignList(rr, req)
+
+	assert.Equal(t, "limit is invalid", err.Error())
+}
+
+func Test_CampaignList_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	service.On("List", mock.Anything, mock.Anything).Return(nil, errExpected)
+
+	req, rr := newHttpTest("GET", "/", nil)
+	req = addContext(req, "email", createdByExpected)
+
+	_, _, err := handler.CampaignList(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
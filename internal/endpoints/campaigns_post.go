@@ -12,6 +12,6 @@ func (h *Handler) CampaignPost(w http.ResponseWriter, r *http.Request) (interfac
 	render.DecodeJSON(r.Body, &request)
 	email := r.Context().Value("email").(string)
 	request.CreatedBy = email
-	id, err := h.CampaignService.Create(request)
+	id, err := h.CampaignService.Create(r.Context(), request)
 	return map[string]string{"id": id}, 201, err
 }
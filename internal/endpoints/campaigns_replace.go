@@ -0,0 +1,53 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	internalerrors "emailgo/internal/internal-errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// CampaignReplace replaces the whole campaign, so unlike CampaignUpdate it
+// refuses a request that leaves a field out.
+func (h *Handler) CampaignReplace(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	id := chi.URLParam(r, "id")
+	var request contract.UpdateCampaignRequest
+	render.DecodeJSON(r.Body, &request)
+
+	if err := requireEveryField(request); err != nil {
+		return nil, 0, err
+	}
+
+	err := h.CampaignService.Update(r.Context(), id, request, callerFrom(r))
+	return nil, 200, err
+}
+
+func requireEveryField(request contract.UpdateCampaignRequest) error {
+	missing := []string{}
+	if request.Name == nil {
+		missing = append(missing, "name")
+	}
+	if request.Subject == nil {
+		missing = append(missing, "subject")
+	}
+	if request.Content == nil {
+		missing = append(missing, "content")
+	}
+	if request.GracePeriod == nil {
+		missing = append(missing, "graceperiod")
+	}
+	if request.Emails == nil && request.Contacts == nil {
+		missing = append(missing, "contacts")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	validationError := &internalerrors.ValidationError{}
+	for _, field := range missing {
+		validationError.Fields = append(validationError.Fields, internalerrors.FieldError{Field: field, Rule: "required", Message: field + " is required"})
+	}
+	return validationError
+}
@@ -0,0 +1,69 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	internalerrors "emailgo/internal/internal-errors"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CampaignReplace_200(t *testing.T) {
+	setupTest()
+	campaignId := "xpto"
+	body := map[string]interface{}{
+		"name":        "new name",
+		"subject":     "new subject",
+		"content":     "new content",
+		"gracePeriod": 0,
+		"emails":      []string{"a@test.com"},
+	}
+
+	service.On("Update", campaignId, mock.MatchedBy(func(request contract.UpdateCampaignRequest) bool {
+		return *request.Name == "new name" && *request.Content == "new content" && len(request.Emails) == 1
+	}), mock.Anything).Return(nil)
+
+	req, rr := newHttpTest("PUT", "/", body)
+	req = addParameter(req, "id", campaignId)
+
+	_, status, err := handler.CampaignReplace(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+	service.AssertExpectations(t)
+}
+
+func Test_CampaignReplace_FieldsLeftOut_ErrValidation(t *testing.T) {
+	setupTest()
+
+	req, rr := newHttpTest("PUT", "/", map[string]string{"name": "new name"})
+	req = addParameter(req, "id", "xpto")
+
+	_, _, err := handler.CampaignReplace(rr, req)
+
+	var validationError *internalerrors.ValidationError
+	assert.True(t, errors.As(err, &validationError))
+	assert.Equal(t, "subject is required; content is required; graceperiod is required; contacts is required", err.Error())
+	service.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_CampaignReplace_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	service.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(errExpected)
+	body := map[string]interface{}{
+		"name":        "new name",
+		"subject":     "new subject",
+		"content":     "new content",
+		"gracePeriod": 0,
+		"contacts":    []map[string]string{{"email": "a@test.com"}},
+	}
+
+	req, rr := newHttpTest("PUT", "/", body)
+
+	_, _, err := handler.CampaignReplace(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
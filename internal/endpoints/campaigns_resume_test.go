@@ -0,0 +1,38 @@
+package endpoints
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CampaignResume_200(t *testing.T) {
+	setupTest()
+	campaignId := "xpto"
+
+	service.On("Resume", mock.MatchedBy(func(id string) bool {
+		return id == campaignId
+	}), mock.Anything).Return(nil)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+	req = addParameter(req, "id", campaignId)
+
+	_, status, err := handler.CampaignResume(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+}
+
+func Test_CampaignResume_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	service.On("Resume", mock.Anything, mock.Anything).Return(errExpected)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+
+	_, _, err := handler.CampaignResume(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
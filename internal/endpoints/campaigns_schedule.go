@@ -0,0 +1,23 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func (h *Handler) CampaignSchedule(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	id := chi.URLParam(r, "id")
+	var request contract.ScheduleCampaignRequest
+	render.DecodeJSON(r.Body, &request)
+	err := h.CampaignService.Schedule(r.Context(), id, request, callerFrom(r))
+	return nil, 200, err
+}
+
+func (h *Handler) CampaignUnschedule(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	id := chi.URLParam(r, "id")
+	err := h.CampaignService.Unschedule(r.Context(), id, callerFrom(r))
+	return nil, 200, err
+}
@@ -0,0 +1,69 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CampaignSchedule_200(t *testing.T) {
+	setupTest()
+	campaignId := "xpto"
+	scheduledFor := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	service.On("Schedule", campaignId, mock.MatchedBy(func(request contract.ScheduleCampaignRequest) bool {
+		return request.ScheduledFor.Equal(scheduledFor)
+	}), mock.Anything).Return(nil)
+
+	req, rr := newHttpTest("PATCH", "/", contract.ScheduleCampaignRequest{ScheduledFor: scheduledFor})
+	req = addParameter(req, "id", campaignId)
+
+	_, status, err := handler.CampaignSchedule(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+	service.AssertExpectations(t)
+}
+
+func Test_CampaignSchedule_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	service.On("Schedule", mock.Anything, mock.Anything, mock.Anything).Return(errExpected)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+
+	_, _, err := handler.CampaignSchedule(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
+
+func Test_CampaignUnschedule_200(t *testing.T) {
+	setupTest()
+	campaignId := "xpto"
+
+	service.On("Unschedule", campaignId, mock.Anything).Return(nil)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+	req = addParameter(req, "id", campaignId)
+
+	_, status, err := handler.CampaignUnschedule(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+}
+
+func Test_CampaignUnschedule_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	service.On("Unschedule", mock.Anything, mock.Anything).Return(errExpected)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+
+	_, _, err := handler.CampaignUnschedule(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
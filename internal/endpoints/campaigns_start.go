@@ -8,6 +8,6 @@ import (
 
 func (h *Handler) CampaignStart(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
 	id := chi.URLParam(r, "id")
-	err := h.CampaignService.Start(id)
+	err := h.CampaignService.Start(r.Context(), id, callerFrom(r))
 	return nil, 200, err
 }
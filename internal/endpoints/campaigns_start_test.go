@@ -14,7 +14,7 @@ func Test_CampaignStart_200(t *testing.T) {
 
 	service.On("Start", mock.MatchedBy(func(id string) bool {
 		return id == campaignId
-	})).Return(nil)
+	}), mock.Anything).Return(nil)
 
 	req, rr := newHttpTest("PATCH", "/", nil)
 	req = addParameter(req, "id", campaignId)
@@ -28,7 +28,7 @@ func Test_CampaignStart_200(t *testing.T) {
 func Test_CampaignStart_Err(t *testing.T) {
 	setupTest()
 	errExpected := errors.New("something wrong")
-	service.On("Start", mock.Anything).Return(errExpected)
+	service.On("Start", mock.Anything, mock.Anything).Return(errExpected)
 
 	req, rr := newHttpTest("PATCH", "/", nil)
 
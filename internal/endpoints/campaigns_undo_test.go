@@ -0,0 +1,38 @@
+package endpoints
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CampaignUndo_200(t *testing.T) {
+	setupTest()
+	campaignId := "xpto"
+
+	service.On("Undo", mock.MatchedBy(func(id string) bool {
+		return id == campaignId
+	}), mock.Anything).Return(nil)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+	req = addParameter(req, "id", campaignId)
+
+	_, status, err := handler.CampaignUndo(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+}
+
+func Test_CampaignUndo_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	service.On("Undo", mock.Anything, mock.Anything).Return(errExpected)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+
+	_, _, err := handler.CampaignUndo(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
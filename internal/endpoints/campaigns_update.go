@@ -0,0 +1,17 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func (h *Handler) CampaignUpdate(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	id := chi.URLParam(r, "id")
+	var request contract.UpdateCampaignRequest
+	render.DecodeJSON(r.Body, &request)
+	err := h.CampaignService.Update(r.Context(), id, request, callerFrom(r))
+	return nil, 200, err
+}
@@ -0,0 +1,41 @@
+package endpoints
+
+import (
+	"emailgo/internal/contract"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CampaignUpdate_200(t *testing.T) {
+	setupTest()
+	campaignId := "xpto"
+	name := "new name"
+
+	service.On("Update", campaignId, mock.MatchedBy(func(request contract.UpdateCampaignRequest) bool {
+		return *request.Name == name && request.Content == nil && request.Emails == nil
+	}), mock.Anything).Return(nil)
+
+	req, rr := newHttpTest("PATCH", "/", map[string]string{"name": name})
+	req = addParameter(req, "id", campaignId)
+
+	_, status, err := handler.CampaignUpdate(rr, req)
+
+	assert.Equal(t, 200, status)
+	assert.Nil(t, err)
+	service.AssertExpectations(t)
+}
+
+func Test_CampaignUpdate_Err(t *testing.T) {
+	setupTest()
+	errExpected := errors.New("something wrong")
+	service.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(errExpected)
+
+	req, rr := newHttpTest("PATCH", "/", nil)
+
+	_, _, err := handler.CampaignUpdate(rr, req)
+
+	assert.Equal(t, errExpected, err)
+}
@@ -0,0 +1,70 @@
+package endpoints
+
+// Keys of the catalog entries that are not problem codes.
+const (
+	messageStatusTransition     = "campaign_status_transition"
+	messageMissingAuthorization = "missing_authorization"
+	messageInvalidToken         = "invalid_token"
+	messageInvalidApiKey        = "invalid_api_key"
+	messageMissingPermission    = "missing_permission"
+	messageAlreadyDispatched    = "campaign_already_dispatched"
+	messageNotPending           = "campaign_not_pending"
+
+	// Validation messages are keyed by the prefix and the rule that failed.
+	// Rules without an entry of their own use messageValidationInvalid.
+	messageValidation        = "validation_"
+	messageValidationInvalid = messageValidation + "invalid"
+)
+
+// catalogs has the messages of every locale the API answers in. The English
+// messages are the texts of the errors they translate.
+var catalogs = map[string]map[string]string{
+	"en": {
+		CodeInternal:                   "Server internal error",
+		CodeNotFound:                   "record not found",
+		CodeForbidden:                  "Forbidden",
+		CodeConflict:                   "Conflict: changed by another request",
+		CodePreconditionFailed:         "Precondition failed: version does not match",
+		CodeCampaignStatusInvalid:      "Campaign status invalid",
+		messageStatusTransition:        "Campaign status invalid: cannot change from {0} to {1}",
+		messageMissingAuthorization:    "request does not contain an authorization header",
+		messageInvalidToken:            "invalid token",
+		messageInvalidApiKey:           "invalid api key",
+		messageMissingPermission:       "user does not have the {0} permission",
+		messageAlreadyDispatched:       "Campaign status invalid: messages were already dispatched",
+		messageNotPending:              "Campaign status invalid: only pending campaigns can be updated",
+		messageValidation + "required": "{0} is required",
+		messageValidation + "min":      "{0} is required with min {1}",
+		messageValidation + "max":      "{0} is required with max {1}",
+		messageValidation + "oneof":    "{0} must be one of {1}",
+		messageValidation + "range":    "{0} must be between 0 and {1}",
+		messageValidation + "future":   "{0} must be in the future",
+		messageValidation + "template": "{0} has an invalid template: {1}",
+		messageValidation + "granted":  "{0} {1} is not granted to the user",
+		messageValidationInvalid:       "{0} is invalid",
+	},
+	"pt_BR": {
+		CodeInternal:                   "Erro interno do servidor",
+		CodeNotFound:                   "registro não encontrado",
+		CodeForbidden:                  "Acesso negado",
+		CodeConflict:                   "Conflito: alterado por outra requisição",
+		CodePreconditionFailed:         "Pré-condição falhou: a versão não confere",
+		CodeCampaignStatusInvalid:      "Status da campanha inválido",
+		messageStatusTransition:        "Status da campanha inválido: não é possível mudar de {0} para {1}",
+		messageMissingAuthorization:    "a requisição não contém um cabeçalho de autorização",
+		messageInvalidToken:            "token inválido",
+		messageInvalidApiKey:           "chave de api inválida",
+		messageMissingPermission:       "usuário não tem a permissão {0}",
+		messageAlreadyDispatched:       "Status da campanha inválido: as mensagens já foram despachadas",
+		messageNotPending:              "Status da campanha inválido: apenas campanhas pendentes podem ser alteradas",
+		messageValidation + "required": "{0} é obrigatório",
+		messageValidation + "min":      "{0} deve ter no mínimo {1}",
+		messageValidation + "max":      "{0} deve ter no máximo {1}",
+		messageValidation + "oneof":    "{0} deve ser um de {1}",
+		messageValidation + "range":    "{0} deve estar entre 0 e {1}",
+		messageValidation + "future":   "{0} deve estar no futuro",
+		messageValidation + "template": "{0} tem um template inválido: {1}",
+		messageValidation + "granted":  "{0} {1} não é concedido ao usuário",
+		messageValidationInvalid:       "{0} é inválido",
+	},
+}
@@ -1,7 +1,11 @@
 package endpoints
 
-import "emailgo/internal/domain/campaign"
+import (
+	"emailgo/internal/domain/apikey"
+	"emailgo/internal/domain/campaign"
+)
 
 type Handler struct {
 	CampaignService campaign.Service
+	ApiKeyService   apikey.Service
 }
@@ -1,29 +1,22 @@
 package endpoints
 
 import (
-	internalerrors "emailgo/internal/internal-errors"
-	"errors"
 	"net/http"
 
 	"github.com/go-chi/render"
-	"gorm.io/gorm"
 )
 
 type EndpointFuc func(w http.ResponseWriter, r *http.Request) (interface{}, int, error)
 
+// HandlerError renders what the endpoint returns, answering errors with
+// problem details in the language the request accepts.
 func HandlerError(endpointFunc EndpointFuc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		obj, status, err := endpointFunc(w, r)
 
 		if err != nil {
-			if errors.Is(err, internalerrors.ErrInternal) {
-				render.Status(r, 500)
-			} else if errors.Is(err, gorm.ErrRecordNotFound) {
-				render.Status(r, 404)
-			} else {
-				render.Status(r, 400)
-			}
-			render.JSON(w, r, map[string]string{"error": err.Error()})
+			translator := translatorFor(r)
+			writeProblem(w, translator, problemFrom(err, translator))
 			return
 		}
 
@@ -1,14 +1,17 @@
 package endpoints
 
 import (
+	"emailgo/internal/domain/campaign"
 	internalerrors "emailgo/internal/internal-errors"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func Test_HandlerError_when_endpoint_returns_internal_error(t *testing.T) {
@@ -26,6 +29,51 @@ func Test_HandlerError_when_endpoint_returns_internal_error(t *testing.T) {
 	assert.Contains(t, res.Body.String(), internalerrors.ErrInternal.Error())
 }
 
+func Test_HandlerError_when_endpoint_returns_forbidden_error(t *testing.T) {
+	endpoint := func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+		return nil, 0, internalerrors.ErrForbidden
+	}
+
+	handlerFunc := HandlerError(endpoint)
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Contains(t, res.Body.String(), internalerrors.ErrForbidden.Error())
+}
+
+func Test_HandlerError_when_endpoint_returns_conflict_error(t *testing.T) {
+	endpoint := func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+		return nil, 0, internalerrors.ErrConflict
+	}
+
+	handlerFunc := HandlerError(endpoint)
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Code)
+	assert.Contains(t, res.Body.String(), internalerrors.ErrConflict.Error())
+}
+
+func Test_HandlerError_when_endpoint_returns_precondition_failed_error(t *testing.T) {
+	endpoint := func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+		return nil, 0, internalerrors.ErrPreconditionFailed
+	}
+
+	handlerFunc := HandlerError(endpoint)
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, res.Code)
+	assert.Contains(t, res.Body.String(), internalerrors.ErrPreconditionFailed.Error())
+}
+
 func Test_HandlerError_when_endpoint_returns_domain_error(t *testing.T) {
 	endpoint := func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
 		return nil, 0, errors.New("Domain error")
@@ -41,6 +89,56 @@ func Test_HandlerError_when_endpoint_returns_domain_error(t *testing.T) {
 	assert.Contains(t, res.Body.String(), "Domain error")
 }
 
+func Test_HandlerError_when_endpoint_returns_validation_error(t *testing.T) {
+	fields := []internalerrors.FieldError{
+		{Field: "name", Rule: "min", Param: "5", Message: "name is required with min 5"},
+		{Field: "contacts[3].email", Rule: "email", Message: "email is invalid"},
+	}
+	endpoint := func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+		return nil, 0, &internalerrors.ValidationError{Fields: fields}
+	}
+
+	handlerFunc := HandlerError(endpoint)
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, ProblemContentType, res.Header().Get("Content-Type"))
+	problem := Problem{}
+	json.Unmarshal(res.Body.Bytes(), &problem)
+	assert.Equal(t, CodeValidationFailed, problem.Code)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, fields, problem.Errors)
+}
+
+func Test_HandlerError_when_endpoint_returns_known_error_return_its_code(t *testing.T) {
+	errs := map[error][2]string{
+		gorm.ErrRecordNotFound: {CodeNotFound, "record not found"},
+		fmt.Errorf("%w: from Done to Started", campaign.ErrStatusInvalid): {CodeCampaignStatusInvalid, "Campaign status invalid"},
+		campaign.ErrNotPending:     {CodeCampaignStatusInvalid, "Campaign status invalid: only pending campaigns can be updated"},
+		errors.New("Domain error"): {CodeBadRequest, "Domain error"},
+	}
+	for err, expected := range errs {
+		endpoint := func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+			return nil, 0, err
+		}
+
+		handlerFunc := HandlerError(endpoint)
+		req, _ := http.NewRequest("GET", "/", nil)
+		res := httptest.NewRecorder()
+
+		handlerFunc.ServeHTTP(res, req)
+
+		problem := Problem{}
+		json.Unmarshal(res.Body.Bytes(), &problem)
+		assert.Equal(t, expected[0], problem.Code)
+		assert.Equal(t, expected[1], problem.Detail)
+		assert.Empty(t, problem.Errors)
+	}
+}
+
 func Test_HandlerError_when_endpoint_returns_obj_and_status(t *testing.T) {
 	type bodyForTest struct {
 		Id int
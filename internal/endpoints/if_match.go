@@ -0,0 +1,41 @@
+package endpoints
+
+import (
+	"emailgo/internal/domain/campaign"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// entityTag is the ETag of a campaign at version.
+func entityTag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// IfMatch makes the campaign service only change a campaign at one of the
+// versions named in the If-Match header, answering 412 otherwise. Without
+// the header, or with "*", any version is changed. Weak and malformed tags
+// match no version.
+func IfMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := strings.TrimSpace(r.Header.Get("If-Match"))
+		if header == "" || header == "*" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		versions := []int{}
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+				continue
+			}
+			if version, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil {
+				versions = append(versions, version)
+			}
+		}
+
+		ctx := campaign.WithExpectedVersions(r.Context(), versions...)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
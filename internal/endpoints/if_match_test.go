@@ -0,0 +1,61 @@
+package endpoints
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	"emailgo/internal/infrastructure/memory"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIfMatchTest serves deletes through IfMatch with a service keeping one
+// campaign, saved once, in memory.
+func newIfMatchTest(t *testing.T) (http.Handler, *memory.CampaignRepository, *campaign.Campaign) {
+	repository := &memory.CampaignRepository{}
+	created, err := campaign.NewCampaign("Campaign X", "Hi", "Body Hi!", []campaign.Contact{{Email: "ana@teste.com"}}, createdByExpected)
+	require.Nil(t, err)
+	require.Nil(t, repository.Create(context.Background(), created))
+	require.Nil(t, repository.Update(context.Background(), created))
+
+	ifMatchHandler := Handler{CampaignService: &campaign.ServiceImp{Repository: repository}}
+	return IfMatch(HandlerError(ifMatchHandler.CampaignDelete)), repository, created
+}
+
+func deleteRequest(id string, ifMatch string) *http.Request {
+	req, _ := newHttpTest("DELETE", "/", nil)
+	req = addParameter(req, "id", id)
+	req = addContext(req, "email", createdByExpected)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	return req
+}
+
+func Test_IfMatch_WhenTagIsCurrentVersion_ChangeCampaign(t *testing.T) {
+	for _, ifMatch := range []string{`"1"`, `"0", "1"`, "*", ""} {
+		server, repository, created := newIfMatchTest(t)
+		_, rr := newHttpTest("DELETE", "/", nil)
+
+		server.ServeHTTP(rr, deleteRequest(created.ID, ifMatch))
+
+		assert.Equal(t, http.StatusOK, rr.Code, ifMatch)
+		_, err := repository.GetBy(context.Background(), created.ID)
+		assert.NotNil(t, err, ifMatch)
+	}
+}
+
+func Test_IfMatch_WhenTagIsNotCurrentVersion_Return412(t *testing.T) {
+	for _, ifMatch := range []string{`"0"`, `W/"1"`, "1", `"one"`} {
+		server, repository, created := newIfMatchTest(t)
+		_, rr := newHttpTest("DELETE", "/", nil)
+
+		server.ServeHTTP(rr, deleteRequest(created.ID, ifMatch))
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code, ifMatch)
+		_, err := repository.GetBy(context.Background(), created.ID)
+		assert.Nil(t, err, ifMatch)
+	}
+}
@@ -0,0 +1,104 @@
+package endpoints
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// translators holds a translator for each language the API answers in,
+// English being the one used when the client accepts none of them.
+var translators = newTranslators()
+
+// baseLocales is the locale used for a language when the client does not
+// ask for a region of it, or asks for one the API does not have.
+var baseLocales = map[string]string{
+	"en": "en",
+	"pt": "pt_BR",
+}
+
+func newTranslators() *ut.UniversalTranslator {
+	universal := ut.New(en.New(), en.New(), pt_BR.New())
+
+	for locale, messages := range catalogs {
+		translator, _ := universal.GetTranslator(locale)
+		for key, text := range messages {
+			if err := translator.Add(key, text, false); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	return universal
+}
+
+// translatorFor returns the translator of the language the request prefers
+// in its Accept-Language header.
+func translatorFor(r *http.Request) ut.Translator {
+	translator, _ := translators.FindTranslator(acceptedLocales(r.Header.Get("Accept-Language"))...)
+	return translator
+}
+
+// acceptedLocales lists the locales of an Accept-Language header, most
+// preferred first, named the way universal-translator names them. Each one
+// is followed by the base locale of its language.
+func acceptedLocales(header string) []string {
+	type accepted struct {
+		locale  string
+		quality float64
+	}
+
+	list := []accepted{}
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		if tag == "" || tag == "*" || quality <= 0 {
+			continue
+		}
+		list = append(list, accepted{locale: strings.ReplaceAll(tag, "-", "_"), quality: quality})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].quality > list[j].quality
+	})
+
+	locales := []string{}
+	for _, item := range list {
+		locales = append(locales, item.locale)
+		language, _, _ := strings.Cut(item.locale, "_")
+		if base, found := baseLocales[strings.ToLower(language)]; found {
+			locales = append(locales, base)
+		}
+	}
+	return locales
+}
+
+// message translates the catalog entry key, filling its placeholders with
+// params.
+func message(translator ut.Translator, key string, params ...string) string {
+	text, err := translator.T(key, params...)
+	if err != nil {
+		return key
+	}
+	return text
+}
+
+// contentLanguage is the language tag of the translator, such as pt-BR.
+func contentLanguage(translator ut.Translator) string {
+	return strings.ReplaceAll(translator.Locale(), "_", "-")
+}
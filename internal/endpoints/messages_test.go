@@ -0,0 +1,138 @@
+package endpoints
+
+import (
+	"emailgo/internal/domain/campaign"
+	internalerrors "emailgo/internal/internal-errors"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TranslatorFor_PickTheLanguageTheRequestPrefers(t *testing.T) {
+	headers := map[string]string{
+		"":                           "en",
+		"pt-BR":                      "pt_BR",
+		"pt":                         "pt_BR",
+		"pt-PT":                      "pt_BR",
+		"en-US,en;q=0.9":             "en",
+		"fr-FR,pt-BR;q=0.8,en;q=0.5": "pt_BR",
+		"en;q=0.5,pt-BR":             "pt_BR",
+		"pt-BR;q=0,en":               "en",
+		"fr, de":                     "en",
+		"*":                          "en",
+	}
+
+	for header, locale := range headers {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", header)
+
+		assert.Equal(t, locale, translatorFor(req).Locale(), header)
+	}
+}
+
+func Test_Catalogs_HaveTheSameEntriesInEveryLocale(t *testing.T) {
+	for locale, messages := range catalogs {
+		for key := range catalogs["en"] {
+			assert.Contains(t, messages, key, locale)
+		}
+		assert.Len(t, messages, len(catalogs["en"]), locale)
+	}
+}
+
+func Test_HandlerError_when_request_accepts_portuguese_translate_problem(t *testing.T) {
+	errs := map[error]string{
+		internalerrors.ErrConflict: "Conflito: alterado por outra requisição",
+		&campaign.StatusTransitionError{From: campaign.Done, To: campaign.Started}: "Status da campanha inválido: não é possível mudar de Done para Started",
+		&internalerrors.ValidationError{Fields: []internalerrors.FieldError{
+			{Field: "name", Rule: "min", Param: "5", Message: "name is required with min 5"},
+			{Field: "contacts[3].email", Rule: "email", Message: "email is invalid"},
+		}}: "name deve ter no mínimo 5; email é inválido",
+	}
+	for err, detail := range errs {
+		endpoint := func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+			return nil, 0, err
+		}
+
+		handlerFunc := HandlerError(endpoint)
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
+		res := httptest.NewRecorder()
+
+		handlerFunc.ServeHTTP(res, req)
+
+		problem := Problem{}
+		json.Unmarshal(res.Body.Bytes(), &problem)
+		assert.Equal(t, detail, problem.Detail)
+		assert.Equal(t, "pt-BR", res.Header().Get("Content-Language"))
+	}
+}
+
+func Test_HandlerError_when_request_accepts_portuguese_translate_domain_error(t *testing.T) {
+	pending := &campaign.Campaign{Status: campaign.Pending}
+	_, cursorErr := campaign.DecodeCursor("not a cursor")
+	errs := map[error]string{
+		pending.Schedule(time.Now().Add(-time.Hour)): "scheduledfor deve estar no futuro",
+		pending.SetGracePeriod(-1):                   "graceperiod deve estar entre 0 e 86400",
+		cursorErr:                                    "cursor é inválido",
+		campaign.ErrAlreadyDispatched:                "Status da campanha inválido: as mensagens já foram despachadas",
+		campaign.ErrNotPending:                       "Status da campanha inválido: apenas campanhas pendentes podem ser alteradas",
+	}
+	for err, detail := range errs {
+		endpoint := func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+			return nil, 0, err
+		}
+
+		handlerFunc := HandlerError(endpoint)
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", "pt-BR")
+		res := httptest.NewRecorder()
+
+		handlerFunc.ServeHTTP(res, req)
+
+		problem := Problem{}
+		json.Unmarshal(res.Body.Bytes(), &problem)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.Equal(t, detail, problem.Detail)
+	}
+}
+
+func Test_HandlerError_when_request_accepts_english_keep_error_text(t *testing.T) {
+	err := &internalerrors.ValidationError{Fields: []internalerrors.FieldError{
+		{Field: "scopes[0]", Rule: "oneof", Param: "read write send", Message: "scopes[0] must be one of read write send"},
+	}}
+	endpoint := func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+		return nil, 0, err
+	}
+
+	handlerFunc := HandlerError(endpoint)
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	problem := Problem{}
+	json.Unmarshal(res.Body.Bytes(), &problem)
+	assert.Equal(t, err.Error(), problem.Detail)
+	assert.Equal(t, err.Fields, problem.Errors)
+	assert.Equal(t, "en", res.Header().Get("Content-Language"))
+}
+
+func Test_Auth_when_request_accepts_portuguese_translate_error(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called")
+	})
+
+	handlerFunc := Auth(nextHandler)
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "pt-BR")
+	res := httptest.NewRecorder()
+
+	handlerFunc.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Contains(t, res.Body.String(), "a requisição não contém um cabeçalho de autorização")
+}
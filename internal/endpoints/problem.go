@@ -0,0 +1,123 @@
+package endpoints
+
+import (
+	"emailgo/internal/domain/campaign"
+	internalerrors "emailgo/internal/internal-errors"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"gorm.io/gorm"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Codes of the problems the API answers with. Unlike titles and details
+// they never change, so clients can rely on them.
+const (
+	CodeBadRequest            = "bad_request"
+	CodeValidationFailed      = "validation_failed"
+	CodeCampaignStatusInvalid = "campaign_status_invalid"
+	CodeUnauthorized          = "unauthorized"
+	CodeForbidden             = "forbidden"
+	CodeNotFound              = "not_found"
+	CodeConflict              = "conflict"
+	CodePreconditionFailed    = "precondition_failed"
+	CodeInternal              = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details body, extended with the code of
+// the problem and, for validation failures, the failed fields.
+type Problem struct {
+	Type   string                      `json:"type"`
+	Title  string                      `json:"title"`
+	Status int                         `json:"status"`
+	Detail string                      `json:"detail,omitempty"`
+	Code   string                      `json:"code"`
+	Errors []internalerrors.FieldError `json:"errors,omitempty"`
+}
+
+func newProblem(status int, code string, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// knownProblems lists the errors the API translates, each one before the
+// errors it wraps. Message is the catalog key of the detail, the code when
+// empty.
+var knownProblems = []struct {
+	err     error
+	status  int
+	code    string
+	message string
+}{
+	{internalerrors.ErrInternal, http.StatusInternalServerError, CodeInternal, ""},
+	{gorm.ErrRecordNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{internalerrors.ErrForbidden, http.StatusForbidden, CodeForbidden, ""},
+	{internalerrors.ErrConflict, http.StatusConflict, CodeConflict, ""},
+	{internalerrors.ErrPreconditionFailed, http.StatusPreconditionFailed, CodePreconditionFailed, ""},
+	{campaign.ErrAlreadyDispatched, http.StatusBadRequest, CodeCampaignStatusInvalid, messageAlreadyDispatched},
+	{campaign.ErrNotPending, http.StatusBadRequest, CodeCampaignStatusInvalid, messageNotPending},
+	{campaign.ErrStatusInvalid, http.StatusBadRequest, CodeCampaignStatusInvalid, ""},
+}
+
+// problemFrom describes an error returned by an endpoint in the language of
+// translator. Errors the API does not know are taken as bad requests and
+// keep their own text.
+func problemFrom(err error, translator ut.Translator) Problem {
+	var validationError *internalerrors.ValidationError
+	if errors.As(err, &validationError) {
+		problem := newProblem(http.StatusBadRequest, CodeValidationFailed, "")
+		messages := make([]string, len(validationError.Fields))
+		for index, field := range validationError.Fields {
+			field.Message = fieldMessage(translator, field)
+			messages[index] = field.Message
+			problem.Errors = append(problem.Errors, field)
+		}
+		problem.Detail = strings.Join(messages, "; ")
+		return problem
+	}
+
+	var transitionError *campaign.StatusTransitionError
+	if errors.As(err, &transitionError) {
+		detail := message(translator, messageStatusTransition, transitionError.From, transitionError.To)
+		return newProblem(http.StatusBadRequest, CodeCampaignStatusInvalid, detail)
+	}
+
+	for _, known := range knownProblems {
+		if errors.Is(err, known.err) {
+			key := known.message
+			if key == "" {
+				key = known.code
+			}
+			return newProblem(known.status, known.code, message(translator, key))
+		}
+	}
+
+	return newProblem(http.StatusBadRequest, CodeBadRequest, err.Error())
+}
+
+// fieldMessage translates the rule a field failed, naming the field by the
+// last element of its path.
+func fieldMessage(translator ut.Translator, field internalerrors.FieldError) string {
+	name := field.Field[strings.LastIndex(field.Field, ".")+1:]
+	text, err := translator.T(messageValidation+field.Rule, name, field.Param)
+	if err != nil {
+		return message(translator, messageValidationInvalid, name)
+	}
+	return text
+}
+
+func writeProblem(w http.ResponseWriter, translator ut.Translator, problem Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("Content-Language", contentLanguage(translator))
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
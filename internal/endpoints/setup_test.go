@@ -3,6 +3,7 @@ package endpoints
 import (
 	"bytes"
 	"context"
+	"emailgo/internal/infrastructure/credential"
 	"emailgo/internal/test/internalmock"
 	"encoding/json"
 	"net/http"
@@ -12,13 +13,16 @@ import (
 )
 
 var (
-	service *internalmock.CampaignServiceMock
-	handler = Handler{}
+	service       *internalmock.CampaignServiceMock
+	apiKeyService *internalmock.ApiKeyServiceMock
+	handler       = Handler{}
 )
 
 func setupTest() {
 	service = new(internalmock.CampaignServiceMock)
+	apiKeyService = new(internalmock.ApiKeyServiceMock)
 	handler.CampaignService = service
+	handler.ApiKeyService = apiKeyService
 }
 
 func newHttpTest(method string, url string, body interface{}) (*http.Request, *httptest.ResponseRecorder) {
@@ -42,3 +46,9 @@ func addContext(req *http.Request, keyParameter string, valueParameter string) *
 	ctx := context.WithValue(req.Context(), keyParameter, valueParameter)
 	return req.WithContext(ctx)
 }
+
+func addPrincipal(req *http.Request, principal *credential.Principal) *http.Request {
+	ctx := context.WithValue(req.Context(), "email", principal.Email)
+	ctx = context.WithValue(ctx, "principal", principal)
+	return req.WithContext(ctx)
+}
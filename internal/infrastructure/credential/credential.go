@@ -3,31 +3,74 @@ package credential
 import (
 	"context"
 	"errors"
-	"os"
 	"strings"
 
 	"github.com/coreos/go-oidc/v3/oidc"
-	jwtgo "github.com/dgrijalva/jwt-go"
 )
 
-func ValidateToken(token string, ctx context.Context) (string, error) {
-	token = strings.Replace(token, "Bearer ", "", 1)
-
-	provider, err := oidc.NewProvider(ctx, os.Getenv("KEYCLOAK"))
+// Validator verifies tokens issued by the provider for the API client.
+// It is built once: the provider is discovered when it is created, and the
+// signing keys are cached and fetched again only when a token is signed by
+// a key the cache does not know, which is how key rotation is picked up.
+type Validator struct {
+	verifier *oidc.IDTokenVerifier
+	clientID string
+}
 
+func NewValidator(ctx context.Context, issuerURL string, clientID string) (*Validator, error) {
+	provider, err := oidc.NewProvider(ctx, issuerURL)
 	if err != nil {
-		return "", errors.New("error to connect to the provider")
+		return nil, errors.New("error to connect to the provider: " + err.Error())
 	}
 
-	verifier := provider.Verifier(&oidc.Config{ClientID: "emailn"})
-	_, err = verifier.Verify(ctx, token)
+	return &Validator{
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+		clientID: clientID,
+	}, nil
+}
+
+// ValidateToken verifies the token and returns its owner with the roles
+// from realm_access and resource_access of the API client.
+func (v *Validator) ValidateToken(token string, ctx context.Context) (*Principal, error) {
+	token = strings.TrimPrefix(token, "Bearer ")
 
+	idToken, err := v.verifier.Verify(ctx, token)
 	if err != nil {
-		return "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
-	tokenJwt, _ := jwtgo.Parse(token, nil)
-	claims := tokenJwt.Claims.(jwtgo.MapClaims)
+	var claims map[string]interface{}
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	return principalFrom(claims, v.clientID)
+}
 
-	return claims["email"].(string), nil
+func principalFrom(claims map[string]interface{}, clientID string) (*Principal, error) {
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return nil, errors.New("token does not contain an email")
+	}
+
+	resourceAccess, _ := claims["resource_access"].(map[string]interface{})
+	return &Principal{
+		Email:       email,
+		RealmRoles:  roles(claims["realm_access"]),
+		ClientRoles: roles(resourceAccess[clientID]),
+	}, nil
+}
+
+// roles reads the "roles" list of a realm_access or resource_access entry.
+func roles(access interface{}) []string {
+	accessClaims, _ := access.(map[string]interface{})
+	values, _ := accessClaims["roles"].([]interface{})
+
+	roles := make([]string, 0, len(values))
+	for _, value := range values {
+		if role, ok := value.(string); ok {
+			roles = append(roles, role)
+		}
+	}
+	return roles
 }
@@ -0,0 +1,54 @@
+package credential
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func claimsOf(t *testing.T, value string) map[string]interface{} {
+	var claims map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func Test_PrincipalFrom_ReadEmailAndRoles(t *testing.T) {
+	claims := claimsOf(t, `{
+		"email": "teste@teste.com",
+		"realm_access": {"roles": ["viewer", "offline_access"]},
+		"resource_access": {
+			"emailn": {"roles": ["sender"]},
+			"account": {"roles": ["manage-account"]}
+		}
+	}`)
+
+	principal, err := principalFrom(claims, "emailn")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "teste@teste.com", principal.Email)
+	assert.Equal(t, []string{"viewer", "offline_access"}, principal.RealmRoles)
+	assert.Equal(t, []string{"sender"}, principal.ClientRoles)
+}
+
+func Test_PrincipalFrom_WithoutRoles_EmptyRoles(t *testing.T) {
+	principal, err := principalFrom(claimsOf(t, `{"email": "teste@teste.com"}`), "emailn")
+
+	assert.Nil(t, err)
+	assert.Empty(t, principal.RealmRoles)
+	assert.Empty(t, principal.ClientRoles)
+}
+
+func Test_PrincipalFrom_EmailIsMissing_Err(t *testing.T) {
+	_, err := principalFrom(claimsOf(t, `{"sub": "123"}`), "emailn")
+
+	assert.Equal(t, "token does not contain an email", err.Error())
+}
+
+func Test_PrincipalFrom_EmailIsNotString_Err(t *testing.T) {
+	_, err := principalFrom(claimsOf(t, `{"email": 42}`), "emailn")
+
+	assert.Equal(t, "token does not contain an email", err.Error())
+}
@@ -0,0 +1,22 @@
+package credential
+
+import "slices"
+
+// Principal is the authenticated user of a request with the roles granted
+// to them in the realm and in the API client. When the request used an API
+// key, ApiKeyID is set and the key scopes take the place of the roles.
+type Principal struct {
+	Email       string
+	RealmRoles  []string
+	ClientRoles []string
+	ApiKeyID    string
+	Scopes      []string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.RealmRoles, role) || slices.Contains(p.ClientRoles, role)
+}
+
+func (p *Principal) IsApiKey() bool {
+	return p.ApiKeyID != ""
+}
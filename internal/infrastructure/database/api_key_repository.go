@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"emailgo/internal/domain/apikey"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ApiKeyRepository struct {
+	Db *gorm.DB
+	// Timeout bounds each operation. Zero means no bound besides the one of
+	// the caller context.
+	Timeout time.Duration
+}
+
+func (a *ApiKeyRepository) Create(ctx context.Context, apiKey *apikey.ApiKey) error {
+	ctx, cancel := withTimeout(ctx, a.Timeout)
+	defer cancel()
+
+	tx := a.Db.WithContext(ctx).Create(apiKey)
+	return tx.Error
+}
+
+func (a *ApiKeyRepository) Update(ctx context.Context, apiKey *apikey.ApiKey) error {
+	ctx, cancel := withTimeout(ctx, a.Timeout)
+	defer cancel()
+
+	tx := a.Db.WithContext(ctx).Save(apiKey)
+	return tx.Error
+}
+
+func (a *ApiKeyRepository) UpdateLastUsed(ctx context.Context, apiKey *apikey.ApiKey) error {
+	ctx, cancel := withTimeout(ctx, a.Timeout)
+	defer cancel()
+
+	tx := a.Db.WithContext(ctx).Model(&apikey.ApiKey{}).
+		Where("id = ? and revoked_on is null", apiKey.ID).
+		Update("last_used_on", apiKey.LastUsedOn)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return apikey.ErrInvalidKey
+	}
+	return nil
+}
+
+func (a *ApiKeyRepository) GetBy(ctx context.Context, id string) (*apikey.ApiKey, error) {
+	ctx, cancel := withTimeout(ctx, a.Timeout)
+	defer cancel()
+
+	var apiKey apikey.ApiKey
+	tx := a.Db.WithContext(ctx).First(&apiKey, "id = ?", id)
+	return &apiKey, tx.Error
+}
+
+func (a *ApiKeyRepository) GetByOwner(ctx context.Context, owner string) ([]apikey.ApiKey, error) {
+	ctx, cancel := withTimeout(ctx, a.Timeout)
+	defer cancel()
+
+	var apiKeys []apikey.ApiKey
+	tx := a.Db.WithContext(ctx).Order("created_on desc").Find(&apiKeys, "owner = ?", owner)
+	return apiKeys, tx.Error
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"emailgo/internal/domain/apikey"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newApiKeyRepository(t *testing.T) *ApiKeyRepository {
+	db := NewDatabase(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "emailgo.db")})
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return &ApiKeyRepository{Db: db}
+}
+
+func Test_ApiKeyRepository_UpdateLastUsed_SavesOnlyLastUsedOn(t *testing.T) {
+	ctx := context.Background()
+	repository := newApiKeyRepository(t)
+	created, _, _ := apikey.NewApiKey("batch jobs", []string{apikey.ScopeRead}, "owner@test.com.br")
+	require.NoError(t, repository.Create(ctx, created))
+
+	used, _ := repository.GetBy(ctx, created.ID)
+	used.Used()
+	used.Name = "changed"
+	err := repository.UpdateLastUsed(ctx, used)
+
+	require.NoError(t, err)
+	saved, _ := repository.GetBy(ctx, created.ID)
+	assert.NotNil(t, saved.LastUsedOn)
+	assert.Equal(t, "batch jobs", saved.Name)
+}
+
+func Test_ApiKeyRepository_UpdateLastUsed_KeyRevokedAfterItWasRead_ErrInvalidKey(t *testing.T) {
+	ctx := context.Background()
+	repository := newApiKeyRepository(t)
+	created, _, _ := apikey.NewApiKey("batch jobs", []string{apikey.ScopeRead}, "owner@test.com.br")
+	require.NoError(t, repository.Create(ctx, created))
+
+	used, _ := repository.GetBy(ctx, created.ID)
+	revoked, _ := repository.GetBy(ctx, created.ID)
+	revoked.Revoke()
+	require.NoError(t, repository.Update(ctx, revoked))
+	used.Used()
+	err := repository.UpdateLastUsed(ctx, used)
+
+	assert.Equal(t, apikey.ErrInvalidKey, err)
+	saved, _ := repository.GetBy(ctx, created.ID)
+	assert.True(t, saved.Revoked())
+	assert.Nil(t, saved.LastUsedOn)
+}
@@ -1,44 +1,333 @@
 package database
 
 import (
+	"context"
 	"emailgo/internal/domain/campaign"
+	internalerrors "emailgo/internal/internal-errors"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CampaignRepository struct {
 	Db *gorm.DB
+	// Timeout bounds each operation. Zero means no bound besides the one of
+	// the caller context.
+	Timeout time.Duration
 }
 
-func (c *CampaignRepository) Create(campaign *campaign.Campaign) error {
-	tx := c.Db.Create(campaign)
+func (c *CampaignRepository) Create(ctx context.Context, campaign *campaign.Campaign) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	tx := c.Db.WithContext(ctx).Create(campaign)
 	return tx.Error
 }
 
-func (c *CampaignRepository) Update(campaign *campaign.Campaign) error {
-	tx := c.Db.Save(campaign)
+// Update saves the campaign with its contacts, dropping the contacts that
+// are no longer in it, after claiming the next version of the campaign.
+func (c *CampaignRepository) Update(ctx context.Context, campaignToUpdate *campaign.Campaign) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	version := campaignToUpdate.Version
+	err := c.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&campaign.Campaign{}).
+			Where("id = ? and version = ?", campaignToUpdate.ID, version).
+			Update("version", version+1)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return internalerrors.ErrConflict
+		}
+		campaignToUpdate.Version = version + 1
+
+		contactIds := make([]string, len(campaignToUpdate.Contacts))
+		for index, contact := range campaignToUpdate.Contacts {
+			contactIds[index] = contact.ID
+		}
+
+		removed := tx.Where("campaign_id = ?", campaignToUpdate.ID)
+		if len(contactIds) > 0 {
+			removed = removed.Where("id not in ?", contactIds)
+		}
+		if err := removed.Delete(&campaign.Contact{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(campaignToUpdate).Error
+	})
+	if err != nil {
+		campaignToUpdate.Version = version
+	}
+	return err
+}
+
+func (c *CampaignRepository) UpdateStatus(ctx context.Context, campaignToUpdate *campaign.Campaign) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	tx := c.Db.WithContext(ctx).Model(&campaign.Campaign{}).
+		Where("id = ? and version = ?", campaignToUpdate.ID, campaignToUpdate.Version).
+		Updates(statusColumns(campaignToUpdate))
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return internalerrors.ErrConflict
+	}
+	campaignToUpdate.Version++
+	return nil
+}
+
+func statusColumns(campaign *campaign.Campaign) map[string]interface{} {
+	return map[string]interface{}{
+		"status":           campaign.Status,
+		"scheduled_for":    campaign.ScheduledFor,
+		"send_at":          campaign.SendAt,
+		"lease_owner":      campaign.LeaseOwner,
+		"lease_expires_on": campaign.LeaseExpiresOn,
+		"updated_on":       campaign.UpdatedOn,
+		"version":          campaign.Version + 1,
+	}
+}
+
+func (c *CampaignRepository) UpdateContact(ctx context.Context, contact *campaign.Contact) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	tx := c.Db.WithContext(ctx).Session(&gorm.Session{FullSaveAssociations: true}).Save(contact)
 	return tx.Error
 }
 
-func (c *CampaignRepository) Get() ([]campaign.Campaign, error) {
+// likeEscaper escapes the wildcards of like, so a name filter matches the
+// text as typed.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (c *CampaignRepository) Get(ctx context.Context, filter campaign.ListFilter) ([]campaign.Campaign, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
 	var campaigns []campaign.Campaign
-	tx := c.Db.Find(&campaigns)
+	tx := c.Db.WithContext(ctx).Preload("Contacts")
+
+	if filter.Status != "" {
+		tx = tx.Where("status = ?", filter.Status)
+	}
+	if filter.CreatedBy != "" {
+		tx = tx.Where("created_by = ?", filter.CreatedBy)
+	}
+	if filter.Name != "" {
+		tx = tx.Where(`lower(name) like ? escape '\'`, "%"+likeEscaper.Replace(strings.ToLower(filter.Name))+"%")
+	}
+	if filter.CreatedFrom != nil {
+		tx = tx.Where("created_on >= ?", filter.CreatedFrom.UTC())
+	}
+	if filter.CreatedTo != nil {
+		tx = tx.Where("created_on <= ?", filter.CreatedTo.UTC())
+	}
+
+	column := "created_on"
+	if filter.SortBy == campaign.SortByName {
+		column = "name"
+	}
+	direction, operator := "asc", ">"
+	if filter.Descending {
+		direction, operator = "desc", "<"
+	}
+
+	if filter.After != nil {
+		var value interface{} = filter.After.Value
+		if column == "created_on" {
+			createdOn, err := time.Parse(time.RFC3339Nano, filter.After.Value)
+			if err != nil {
+				return nil, err
+			}
+			value = createdOn.UTC()
+		}
+		tx = tx.Where(fmt.Sprintf("%s %s ? or (%s = ? and id %s ?)", column, operator, column, operator), value, value, filter.After.ID)
+	}
+
+	tx = tx.Order(column + " " + direction).Order("id " + direction).Limit(filter.Limit).Find(&campaigns)
 	return campaigns, tx.Error
 }
 
-func (c *CampaignRepository) GetBy(id string) (*campaign.Campaign, error) {
+func (c *CampaignRepository) GetBy(ctx context.Context, id string) (*campaign.Campaign, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
 	var campaign campaign.Campaign
-	tx := c.Db.Preload("Contacts").First(&campaign, "id = ?", id)
+	tx := c.Db.WithContext(ctx).Preload("Contacts").First(&campaign, "id = ?", id)
 	return &campaign, tx.Error
 }
 
-func (c *CampaignRepository) Delete(campaign *campaign.Campaign) error {
-	tx := c.Db.Select("Contacts").Delete(campaign)
+func (c *CampaignRepository) Delete(ctx context.Context, campaignToDelete *campaign.Campaign) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	return c.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("campaign_id = ?", campaignToDelete.ID).Delete(&campaign.Contact{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ? and version = ?", campaignToDelete.ID, campaignToDelete.Version).
+			Delete(&campaign.Campaign{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return internalerrors.ErrConflict
+		}
+		return nil
+	})
+}
+
+// Claim locks the due campaigns other workers are not already looking at and
+// leases them to owner. Each lease is taken with an update conditioned on the
+// version read, so two workers can never both win a campaign. SQLite
+// has no row locks, so there the conditional update is the only guard.
+func (c *CampaignRepository) Claim(ctx context.Context, owner string, leaseUntil time.Time, limit int) ([]campaign.Campaign, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var claimed []campaign.Campaign
+
+	err := c.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		query := tx
+		if IsPostgres(tx) {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+
+		var candidates []campaign.Campaign
+		err := query.
+			Where("(status = ? and coalesce(send_at, updated_on) <= ?) or (status = ? and scheduled_for <= ?) or (status = ? and lease_expires_on < ?)",
+				campaign.Started, now, campaign.Scheduled, now, campaign.Sending, now).
+			Order("updated_on").Limit(limit).Find(&candidates).Error
+		if err != nil {
+			return err
+		}
+
+		ids := []string{}
+		for index := range candidates {
+			candidate := &candidates[index]
+			if candidate.Claim(owner, leaseUntil) != nil {
+				continue
+			}
+
+			result := tx.Model(&campaign.Campaign{}).
+				Where("id = ? and version = ?", candidate.ID, candidate.Version).
+				Updates(map[string]interface{}{
+					"status":           candidate.Status,
+					"lease_owner":      candidate.LeaseOwner,
+					"lease_expires_on": candidate.LeaseExpiresOn,
+					"updated_on":       candidate.UpdatedOn,
+					"version":          candidate.Version + 1,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 1 {
+				ids = append(ids, candidate.ID)
+			}
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Preload("Contacts").Find(&claimed, "id in ?", ids).Error
+	})
+
+	return claimed, err
+}
+
+func (c *CampaignRepository) RenewLease(ctx context.Context, campaignToRenew *campaign.Campaign, owner string) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	tx := c.Db.WithContext(ctx).Model(&campaign.Campaign{}).
+		Where("id = ? and status = ? and lease_owner = ?", campaignToRenew.ID, campaign.Sending, owner).
+		Update("lease_expires_on", campaignToRenew.LeaseExpiresOn)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return campaign.ErrLeaseLost
+	}
+	return nil
+}
+
+func (c *CampaignRepository) Release(ctx context.Context, campaignToRelease *campaign.Campaign, owner string) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	tx := c.Db.WithContext(ctx).Model(&campaign.Campaign{}).
+		Where("id = ? and status = ? and lease_owner = ? and version = ?",
+			campaignToRelease.ID, campaign.Sending, owner, campaignToRelease.Version).
+		Updates(statusColumns(campaignToRelease))
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return campaign.ErrLeaseLost
+	}
+	campaignToRelease.Version++
+	return nil
+}
+
+// CampaignsChannel is the Postgres channel started campaigns are notified
+// on, with a CampaignNotification as JSON payload.
+const CampaignsChannel = "campaigns_to_be_sent"
+
+// CampaignNotification tells the workers which campaign was started and
+// when its grace period is over.
+type CampaignNotification struct {
+	ID     string    `json:"id"`
+	SendAt time.Time `json:"sendAt"`
+}
+
+// Notify wakes the listening workers. Without Postgres there is nobody
+// listening and the workers find the campaign on their next poll.
+func (c *CampaignRepository) Notify(ctx context.Context, campaignToSend *campaign.Campaign) error {
+	if !IsPostgres(c.Db) {
+		return nil
+	}
+
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	notification := CampaignNotification{ID: campaignToSend.ID, SendAt: time.Now()}
+	if campaignToSend.SendAt != nil {
+		notification.SendAt = *campaignToSend.SendAt
+	}
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	tx := c.Db.WithContext(ctx).Exec("select pg_notify(?, ?)", CampaignsChannel, string(payload))
 	return tx.Error
 }
 
-func (c *CampaignRepository) GetCampaignsToBeSent() ([]campaign.Campaign, error) {
-	var campaigns []campaign.Campaign
-	tx := c.Db.Preload("Contacts").Find(&campaigns, "status = ? and date_part('minute', now()::timestamp - updated_on::timestamp) >= ?", campaign.Started, 1)
-	return campaigns, tx.Error
+func (c *CampaignRepository) GetDeliveries(ctx context.Context, campaignId string) ([]campaign.Delivery, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var deliveries []campaign.Delivery
+	tx := c.Db.WithContext(ctx).Find(&deliveries, "campaign_id = ?", campaignId)
+	return deliveries, tx.Error
+}
+
+func (c *CampaignRepository) SaveDelivery(ctx context.Context, delivery *campaign.Delivery) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	tx := c.Db.WithContext(ctx).Save(delivery)
+	return tx.Error
 }
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	"emailgo/internal/test/repositorytest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLiteDatabase(t *testing.T) *CampaignRepository {
+	db := NewDatabase(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "emailgo.db")})
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return &CampaignRepository{Db: db}
+}
+
+func Test_CampaignRepository_Contract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) campaign.Repository {
+		return newSQLiteDatabase(t)
+	})
+}
+
+// SQLite compares times as text, so they must all be saved and queried in
+// the same zone whatever the zone of the host.
+func Test_CampaignRepository_Get_HostIsNotOnUTC_PageByCreatedOn(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("-03", -3*60*60)
+	t.Cleanup(func() { time.Local = local })
+	ctx := context.Background()
+	repository := newSQLiteDatabase(t)
+
+	created := []string{}
+	for _, name := range []string{"Campaign A", "Campaign B", "Campaign C"} {
+		newCampaign, err := campaign.NewCampaign(name, "Hi", "Body Hi!", []campaign.Contact{{Email: "ana@teste.com"}}, "ana@teste.com")
+		require.Nil(t, err)
+		require.Nil(t, repository.Create(ctx, newCampaign))
+		created = append(created, newCampaign.ID)
+	}
+
+	for _, descending := range []bool{false, true} {
+		filter := campaign.ListFilter{SortBy: campaign.SortByCreatedOn, Descending: descending, Limit: 2}
+		paged := []string{}
+		for page := 0; page < 3; page++ {
+			campaigns, err := repository.Get(ctx, filter)
+			require.Nil(t, err)
+			for _, item := range campaigns {
+				paged = append(paged, item.ID)
+			}
+			if len(campaigns) < filter.Limit {
+				break
+			}
+			filter.After = campaign.NewCursor(&campaigns[len(campaigns)-1], campaign.SortByCreatedOn)
+		}
+
+		expected := append([]string(nil), created...)
+		if descending {
+			expected = []string{created[2], created[1], created[0]}
+		}
+		assert.Equal(t, expected, paged)
+	}
+
+	first, _ := repository.GetBy(ctx, created[0])
+	campaigns, err := repository.Get(ctx, campaign.ListFilter{CreatedFrom: &first.CreatedOn, SortBy: campaign.SortByCreatedOn, Limit: 10})
+	require.Nil(t, err)
+	assert.Len(t, campaigns, 3)
+	campaigns, _ = repository.Get(ctx, campaign.ListFilter{CreatedTo: &first.CreatedOn, SortBy: campaign.SortByCreatedOn, Limit: 10})
+	assert.Len(t, campaigns, 1)
+}
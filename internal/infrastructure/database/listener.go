@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// reconnectDelay is how long ListenCampaigns waits before connecting again
+// after the connection failed.
+const reconnectDelay = 5 * time.Second
+
+// ListenCampaigns calls wake each time a campaign is notified on
+// CampaignsChannel, until ctx is done. It holds its own connection, outside
+// the GORM pool, and reconnects when it drops.
+func ListenCampaigns(ctx context.Context, dsn string, wake func(notification CampaignNotification)) {
+	for ctx.Err() == nil {
+		err := listen(ctx, dsn, wake)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Println("Error listening for campaigns:", err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func listen(ctx context.Context, dsn string, wake func(notification CampaignNotification)) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "listen "+pgx.Identifier{CampaignsChannel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		received, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var notification CampaignNotification
+		if err = json.Unmarshal([]byte(received.Payload), &notification); err != nil {
+			log.Println("Invalid campaign notification:", received.Payload)
+			continue
+		}
+		wake(notification)
+	}
+}
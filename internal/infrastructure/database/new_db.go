@@ -1,22 +1,81 @@
 package database
 
 import (
+	"context"
+	"emailgo/internal/domain/apikey"
 	"emailgo/internal/domain/campaign"
-	"os"
+	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func NewDatabase() *gorm.DB {
-	dsn := os.Getenv("DATABASE")
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// Drivers accepted in Config.Driver. Postgres is the default; SQLite is
+// meant for local development and CI, with the DSN holding the file path.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+type Config struct {
+	Driver string
+	DSN    string
+	// Timeout bounds each query. Zero means no bound.
+	Timeout time.Duration
+}
+
+func NewDatabase(config Config) *gorm.DB {
+	var dialector gorm.Dialector
+	switch config.Driver {
+	case "", DriverPostgres:
+		dialector = postgres.Open(config.DSN)
+	case DriverSQLite:
+		dialector = sqlite.Open(config.DSN)
+	default:
+		panic("Unknown database driver " + config.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
 
 	if err != nil {
 		panic("Fail to conect database")
 	}
 
-	db.AutoMigrate(&campaign.Campaign{}, &campaign.Contact{})
+	saveInUTC(db)
+	if db.Dialector.Name() == DriverSQLite {
+		configureSQLite(db)
+	}
+
+	db.AutoMigrate(&campaign.Campaign{}, &campaign.Contact{}, &campaign.DeliveryAttempt{}, &campaign.Delivery{}, &apikey.ApiKey{})
 
 	return db
 }
+
+// configureSQLite keeps a single connection, since SQLite allows one writer
+// at a time, and makes it wait for the other process (API or worker) to
+// finish writing instead of failing with "database is locked".
+func configureSQLite(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic("Fail to conect database")
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+
+	db.Exec("pragma busy_timeout = 5000")
+	db.Exec("pragma journal_mode = wal")
+}
+
+// IsPostgres tells whether db supports row locks and LISTEN/NOTIFY.
+func IsPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == DriverPostgres
+}
+
+// withTimeout bounds one repository operation by timeout, when there is one.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
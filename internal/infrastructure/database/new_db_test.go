@@ -0,0 +1,34 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewDatabase_SQLite_ConfigureSingleWriter(t *testing.T) {
+	db := NewDatabase(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "emailgo.db")})
+	sqlDB, err := db.DB()
+	require.Nil(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	var busyTimeout int
+	var journalMode string
+	db.Raw("pragma busy_timeout").Scan(&busyTimeout)
+	db.Raw("pragma journal_mode").Scan(&journalMode)
+
+	assert.Equal(t, DriverSQLite, db.Dialector.Name())
+	assert.False(t, IsPostgres(db))
+	assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections)
+	assert.Equal(t, 5000, busyTimeout)
+	assert.Equal(t, "wal", journalMode)
+	assert.True(t, db.Migrator().HasTable("campaigns"))
+}
+
+func Test_NewDatabase_DriverIsUnknown_Panic(t *testing.T) {
+	assert.PanicsWithValue(t, "Unknown database driver mysql", func() {
+		NewDatabase(Config{Driver: "mysql"})
+	})
+}
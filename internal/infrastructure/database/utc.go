@@ -0,0 +1,71 @@
+package database
+
+import (
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// saveInUTC makes every time be saved in UTC. SQLite keeps times as text
+// with the zone they were given in and compares them as text, so times of
+// different zones would not sort nor compare by the instant they stand for.
+// Times used as query parameters must be given in UTC as well.
+func saveInUTC(db *gorm.DB) {
+	db.Config.NowFunc = func() time.Time {
+		return time.Now().UTC()
+	}
+	db.Callback().Create().Before("gorm:create").Register("emailgo:utc", timesToUTC)
+	db.Callback().Update().Before("gorm:update").Register("emailgo:utc", timesToUTC)
+}
+
+func timesToUTC(db *gorm.DB) {
+	statement := db.Statement
+	if values, ok := statement.Dest.(map[string]interface{}); ok {
+		for column, value := range values {
+			values[column] = inUTC(value)
+		}
+		return
+	}
+	if statement.Schema == nil {
+		return
+	}
+
+	model := statement.ReflectValue
+	switch model.Kind() {
+	case reflect.Slice, reflect.Array:
+		for index := 0; index < model.Len(); index++ {
+			fieldsToUTC(db, reflect.Indirect(model.Index(index)))
+		}
+	case reflect.Struct:
+		fieldsToUTC(db, model)
+	}
+}
+
+func fieldsToUTC(db *gorm.DB, model reflect.Value) {
+	ctx := db.Statement.Context
+	for _, field := range db.Statement.Schema.Fields {
+		value, zero := field.ValueOf(ctx, model)
+		if zero {
+			continue
+		}
+		switch value.(type) {
+		case time.Time, *time.Time:
+			field.Set(ctx, model, inUTC(value))
+		}
+	}
+}
+
+func inUTC(value interface{}) interface{} {
+	switch t := value.(type) {
+	case time.Time:
+		return t.UTC()
+	case *time.Time:
+		if t == nil {
+			return t
+		}
+		utc := t.UTC()
+		return &utc
+	}
+	return value
+}
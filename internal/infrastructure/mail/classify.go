@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"syscall"
+)
+
+// classifySMTP turns an error of an SMTP transaction into a delivery error:
+// 4xx replies, timeouts, cancellations and broken connections are temporary,
+// 5xx replies are permanent.
+func classifySMTP(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var protocolError *textproto.Error
+	if errors.As(err, &protocolError) {
+		return &campaign.DeliveryError{
+			Code:      protocolError.Code,
+			Temporary: protocolError.Code >= 400 && protocolError.Code < 500,
+			Err:       err,
+		}
+	}
+
+	return &campaign.DeliveryError{Temporary: isConnectionError(err), Err: err}
+}
+
+// classifyHTTP turns a provider answer into a delivery error: no answer at
+// all, rate limiting and server errors are temporary, the other 4xx answers
+// are permanent.
+func classifyHTTP(statusCode int, err error) error {
+	temporary := statusCode == 0 || statusCode == 408 || statusCode == 429 || statusCode >= 500
+	return &campaign.DeliveryError{Code: statusCode, Temporary: temporary, Err: err}
+}
+
+func isConnectionError(err error) bool {
+	var netError net.Error
+	if errors.As(err, &netError) {
+		return true
+	}
+
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
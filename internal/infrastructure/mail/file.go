@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	"os"
+	"path/filepath"
+)
+
+// FileMailer writes each message as an .eml file in Dir instead of sending
+// it. The file is written under a temporary name and renamed when complete,
+// so readers never see a partial message.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *FileMailer) Send(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+	message, err := newMessage(m.From, campaign, contact)
+	if err != nil {
+		return err
+	}
+
+	content, err := messageBytes(message)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := filepath.Join(m.Dir, campaign.ID+"-"+contact.ID+".eml")
+	temporary := name + ".tmp"
+	if err = os.WriteFile(temporary, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, name)
+}
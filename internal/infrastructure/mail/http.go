@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"emailgo/internal/domain/campaign"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPMailer posts each message as JSON to an email provider API:
+//
+//	{"from": "...", "to": "...", "subject": "...", "html": "...", "message_id": "..."}
+//
+// Any response outside 2xx is returned as a *campaign.DeliveryError, which is
+// temporary for rate limiting, server errors and connection failures.
+type HTTPMailer struct {
+	URL   string
+	Token string
+	From  string
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+}
+
+type httpMessage struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Subject   string `json:"subject"`
+	HTML      string `json:"html"`
+	MessageID string `json:"message_id,omitempty"`
+}
+
+func (m *HTTPMailer) Send(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+	subject, body, err := campaign.Render(contact)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(httpMessage{From: m.From, To: contact.Email, Subject: subject, HTML: body, MessageID: contact.MessageId})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, m.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if m.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+m.Token)
+	}
+
+	client := m.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return classifyHTTP(0, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return classifyHTTP(response.StatusCode, fmt.Errorf("email provider answered %d: %s", response.StatusCode, bytes.TrimSpace(responseBody)))
+	}
+
+	return nil
+}
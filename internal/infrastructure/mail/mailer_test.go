@@ -0,0 +1,120 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"emailgo/internal/domain/campaign"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCampaignToSend(t *testing.T) *campaign.Campaign {
+	campaignToSend, err := campaign.NewCampaign(
+		"Campaign X",
+		"Hi {{.FirstName}}",
+		"<p>Hello {{.FirstName}}</p>",
+		[]campaign.Contact{{Email: "a@test.com", FirstName: "Ana"}},
+		"teste@teste.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return campaignToSend
+}
+
+func Test_FileMailer_WriteEmlFile(t *testing.T) {
+	campaignToSend := newCampaignToSend(t)
+	contact := &campaignToSend.Contacts[0]
+	contact.MessageId = "<1@test.com>"
+	mailer := &FileMailer{Dir: t.TempDir(), From: "from@test.com"}
+
+	err := mailer.Send(context.Background(), campaignToSend, contact)
+
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(mailer.Dir, campaignToSend.ID+"-"+contact.ID+".eml"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "To: a@test.com")
+	assert.Contains(t, string(content), "Subject: Hi Ana")
+	assert.Contains(t, string(content), "Message-ID: <1@test.com>")
+	assert.Contains(t, string(content), "<p>Hello Ana</p>")
+	temporaries, _ := filepath.Glob(filepath.Join(mailer.Dir, "*.tmp"))
+	assert.Empty(t, temporaries)
+}
+
+func Test_StdoutMailer_PrintMessage(t *testing.T) {
+	campaignToSend := newCampaignToSend(t)
+	var output bytes.Buffer
+	mailer := &StdoutMailer{From: "from@test.com", Writer: &output}
+
+	err := mailer.Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), "From: from@test.com")
+	assert.Contains(t, output.String(), "<p>Hello Ana</p>")
+}
+
+func Test_HTTPMailer_PostJsonWithToken(t *testing.T) {
+	campaignToSend := newCampaignToSend(t)
+	var received httpMessage
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	mailer := &HTTPMailer{URL: server.URL, Token: "secret", From: "from@test.com"}
+
+	err := mailer.Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, httpMessage{From: "from@test.com", To: "a@test.com", Subject: "Hi Ana", HTML: "<p>Hello Ana</p>"}, received)
+}
+
+func Test_HTTPMailer_ProviderRejects_ReturnError(t *testing.T) {
+	campaignToSend := newCampaignToSend(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid recipient", http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+	mailer := &HTTPMailer{URL: server.URL, From: "from@test.com"}
+
+	err := mailer.Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.EqualError(t, err, "email provider answered 422: invalid recipient")
+	assert.False(t, campaign.IsTemporary(err))
+}
+
+func Test_HTTPMailer_ProviderUnavailable_TemporaryError(t *testing.T) {
+	campaignToSend := newCampaignToSend(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	mailer := &HTTPMailer{URL: server.URL, From: "from@test.com"}
+
+	err := mailer.Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.True(t, campaign.IsTemporary(err))
+}
+
+func Test_NewMailer_TransportInvalid_ReturnError(t *testing.T) {
+	_, err := NewMailer(Config{Transport: "pigeon"})
+
+	assert.EqualError(t, err, "MAIL_TRANSPORT is invalid: pigeon")
+}
+
+func Test_NewMailer_DefaultIsSmtp(t *testing.T) {
+	mailer, err := NewMailer(Config{Port: 465, TLS: TLSImplicit})
+
+	assert.Nil(t, err)
+	smtpMailer := mailer.(*SMTPMailer)
+	assert.Equal(t, 465, smtpMailer.Port)
+	assert.Equal(t, TLSImplicit, smtpMailer.TLS)
+}
@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"bytes"
+	"emailgo/internal/domain/campaign"
+
+	"gopkg.in/gomail.v2"
+)
+
+// newMessage renders the campaign for the contact into a message ready to be
+// written in RFC 5322 format.
+func newMessage(from string, campaign *campaign.Campaign, contact *campaign.Contact) (*gomail.Message, error) {
+	subject, body, err := campaign.Render(contact)
+	if err != nil {
+		return nil, err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", from)
+	m.SetHeader("To", contact.Email)
+	m.SetHeader("Subject", subject)
+	if contact.MessageId != "" {
+		m.SetHeader("Message-ID", contact.MessageId)
+	}
+	m.SetBody("text/html", body)
+	return m, nil
+}
+
+func messageBytes(m *gomail.Message) ([]byte, error) {
+	var buffer bytes.Buffer
+	if _, err := m.WriteTo(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
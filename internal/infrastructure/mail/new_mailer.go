@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"emailgo/internal/domain/campaign"
+	"errors"
+)
+
+const (
+	TransportSMTP   = "smtp"
+	TransportFile   = "file"
+	TransportStdout = "stdout"
+	TransportHTTP   = "http"
+)
+
+// Config selects and sets up a mailer. Zero values take the defaults of
+// the transport.
+type Config struct {
+	// Transport is smtp by default.
+	Transport string
+	From      string
+
+	// Host to Auth set up the smtp transport. Port is 587 by default.
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLS      string
+	Auth     string
+
+	// FileDir is where the file transport writes, mails by default.
+	FileDir string
+
+	// HTTPURL and HTTPToken set up the http transport.
+	HTTPURL   string
+	HTTPToken string
+}
+
+// NewMailer builds the mailer selected by config.Transport.
+func NewMailer(config Config) (campaign.Mailer, error) {
+	switch config.Transport {
+	case "", TransportSMTP:
+		port := config.Port
+		if port == 0 {
+			port = 587
+		}
+		return &SMTPMailer{
+			Host:     config.Host,
+			Port:     port,
+			Username: config.Username,
+			Password: config.Password,
+			From:     config.From,
+			TLS:      config.TLS,
+			Auth:     config.Auth,
+		}, nil
+	case TransportFile:
+		dir := config.FileDir
+		if dir == "" {
+			dir = "mails"
+		}
+		return &FileMailer{Dir: dir, From: config.From}, nil
+	case TransportStdout:
+		return &StdoutMailer{From: config.From}, nil
+	case TransportHTTP:
+		return &HTTPMailer{URL: config.HTTPURL, Token: config.HTTPToken, From: config.From}, nil
+	default:
+		return nil, errors.New("MAIL_TRANSPORT is invalid: " + config.Transport)
+	}
+}
@@ -0,0 +1,206 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"emailgo/internal/domain/campaign"
+	"errors"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	TLSStartTLS = "starttls"
+	TLSImplicit = "implicit"
+	TLSNone     = "none"
+
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+	AuthNone    = "none"
+)
+
+// SMTPMailer sends each message over its own SMTP connection.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// TLS is TLSStartTLS (the default), TLSImplicit or TLSNone.
+	TLS string
+	// Auth is AuthPlain (the default), AuthLogin, AuthCRAMMD5 or AuthNone.
+	Auth      string
+	Timeout   time.Duration
+	TLSConfig *tls.Config
+}
+
+// Send returns a *campaign.DeliveryError telling temporary and permanent
+// failures apart when the SMTP transaction fails. The transaction is broken
+// off when ctx is done, which is a temporary failure.
+func (m *SMTPMailer) Send(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+	message, err := newMessage(m.From, campaign, contact)
+	if err != nil {
+		return err
+	}
+
+	return classifySMTP(m.send(ctx, message, contact.Email))
+}
+
+func (m *SMTPMailer) send(ctx context.Context, message *gomail.Message, to string) error {
+	// From may name the sender, as in Name <addr@host>, which only the
+	// header keeps.
+	from, err := netmail.ParseAddress(m.From)
+	if err != nil {
+		return errors.New("sender address is invalid: " + err.Error())
+	}
+
+	client, stop, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer stop()
+	defer client.Close()
+
+	if auth := m.auth(); auth != nil {
+		if err = client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = message.WriteTo(writer); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	// The server accepted the message once DATA was closed, so a failure
+	// to quit must not get it sent again.
+	client.Quit()
+	return nil
+}
+
+// dial connects to the server. Until stop is called, ctx being done expires
+// the connection deadline, so the command in progress fails right away
+// instead of waiting for the server.
+func (m *SMTPMailer) dial(ctx context.Context) (client *smtp.Client, stop func() bool, err error) {
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	address := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	if m.TLS == TLSImplicit {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: m.tlsConfig()}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	stop = context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+
+	client, err = smtp.NewClient(conn, m.Host)
+	if err != nil {
+		stop()
+		conn.Close()
+		return nil, nil, err
+	}
+
+	if m.TLS == "" || m.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			stop()
+			client.Close()
+			return nil, nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err = client.StartTLS(m.tlsConfig()); err != nil {
+			stop()
+			client.Close()
+			return nil, nil, err
+		}
+	}
+
+	return client, stop, nil
+}
+
+func (m *SMTPMailer) tlsConfig() *tls.Config {
+	if m.TLSConfig != nil {
+		return m.TLSConfig
+	}
+	return &tls.Config{ServerName: m.Host}
+}
+
+func (m *SMTPMailer) auth() smtp.Auth {
+	if m.Username == "" {
+		return nil
+	}
+
+	switch m.Auth {
+	case AuthNone:
+		return nil
+	case AuthLogin:
+		return &loginAuth{username: m.Username, password: m.Password}
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(m.Username, m.Password)
+	default:
+		return smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp does not provide
+// but some providers still require.
+type loginAuth struct {
+	username string
+	password string
+}
+
+// Start refuses, as smtp.PlainAuth does, to send the password over a
+// connection that is not encrypted unless the server is this host.
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch string(fromServer) {
+	case "Username:", "username:":
+		return []byte(a.username), nil
+	case "Password:", "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, errors.New("unexpected LOGIN challenge: " + string(fromServer))
+	}
+}
@@ -0,0 +1,234 @@
+package mail
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	"emailgo/internal/test/internalmock"
+	"emailgo/internal/test/smtptest"
+	"errors"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newSMTPMailer(server *smtptest.Server) *SMTPMailer {
+	return &SMTPMailer{
+		Host:    server.Host,
+		Port:    server.Port,
+		From:    "from@test.com",
+		TLS:     TLSNone,
+		Timeout: time.Second,
+	}
+}
+
+func Test_SMTPMailer_SendRenderedMessage(t *testing.T) {
+	server := smtptest.NewServer(t)
+	campaignToSend := newCampaignToSend(t)
+
+	err := newSMTPMailer(server).Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.Nil(t, err)
+	messages := server.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, []string{"a@test.com"}, messages[0].To)
+	assert.Contains(t, string(messages[0].Data), "Subject: Hi Ana")
+	assert.Contains(t, string(messages[0].Data), "<p>Hello Ana</p>")
+}
+
+func Test_SMTPMailer_FromHasName_SendAddressOnlyInEnvelope(t *testing.T) {
+	server := smtptest.NewServer(t)
+	campaignToSend := newCampaignToSend(t)
+	mailer := newSMTPMailer(server)
+	mailer.From = "Emailgo <from@test.com>"
+
+	err := mailer.Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.Nil(t, err)
+	messages := server.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "from@test.com", messages[0].From)
+	assert.Contains(t, string(messages[0].Data), "From: Emailgo <from@test.com>")
+}
+
+func Test_SMTPMailer_FromIsInvalid_PermanentError(t *testing.T) {
+	server := smtptest.NewServer(t)
+	campaignToSend := newCampaignToSend(t)
+	mailer := newSMTPMailer(server)
+	mailer.From = "not an address"
+
+	err := mailer.Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.ErrorContains(t, err, "sender address is invalid")
+	assert.False(t, campaign.IsTemporary(err))
+	assert.Empty(t, server.Messages())
+}
+
+func Test_SMTPMailer_StartTLSWithLoginAuth(t *testing.T) {
+	server := smtptest.NewTLSServer(t)
+	campaignToSend := newCampaignToSend(t)
+	mailer := newSMTPMailer(server)
+	mailer.TLS = TLSStartTLS
+	mailer.TLSConfig = server.ClientTLSConfig()
+	mailer.Auth = AuthLogin
+	mailer.Username = "user"
+	mailer.Password = "secret"
+
+	err := mailer.Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.Nil(t, err)
+	assert.Equal(t, "user", server.Messages()[0].Username)
+}
+
+func Test_LoginAuth_UnencryptedConnection_ReturnError(t *testing.T) {
+	auth := &loginAuth{username: "user", password: "secret"}
+
+	_, _, errRemote := auth.Start(&smtp.ServerInfo{Name: "smtp.test.com", TLS: false})
+	_, _, errLocal := auth.Start(&smtp.ServerInfo{Name: "localhost", TLS: false})
+	mechanism, _, errTLS := auth.Start(&smtp.ServerInfo{Name: "smtp.test.com", TLS: true})
+
+	assert.EqualError(t, errRemote, "unencrypted connection")
+	assert.Nil(t, errLocal)
+	assert.Nil(t, errTLS)
+	assert.Equal(t, "LOGIN", mechanism)
+}
+
+func Test_SMTPMailer_StartTLSNotOffered_ReturnError(t *testing.T) {
+	server := smtptest.NewServer(t)
+	campaignToSend := newCampaignToSend(t)
+	mailer := newSMTPMailer(server)
+	mailer.TLS = TLSStartTLS
+
+	err := mailer.Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.EqualError(t, err, "smtp server does not support STARTTLS")
+}
+
+func Test_SMTPMailer_RecipientRejected_PermanentError(t *testing.T) {
+	server := smtptest.NewServer(t)
+	server.RejectRecipient("a@test.com", 550, "mailbox unavailable")
+	campaignToSend := newCampaignToSend(t)
+
+	err := newSMTPMailer(server).Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	var deliveryError *campaign.DeliveryError
+	assert.True(t, errors.As(err, &deliveryError))
+	assert.Equal(t, 550, deliveryError.Code)
+	assert.False(t, deliveryError.Temporary)
+	assert.Empty(t, server.Messages())
+}
+
+func Test_SMTPMailer_Greylisted_TemporaryError(t *testing.T) {
+	server := smtptest.NewServer(t)
+	server.Reject(smtptest.StageRcpt, 451, "greylisted, try again later")
+	campaignToSend := newCampaignToSend(t)
+
+	err := newSMTPMailer(server).Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	var deliveryError *campaign.DeliveryError
+	assert.True(t, errors.As(err, &deliveryError))
+	assert.Equal(t, 451, deliveryError.Code)
+	assert.True(t, campaign.IsTemporary(err))
+}
+
+func Test_SMTPMailer_ConnectionDropped_TemporaryError(t *testing.T) {
+	server := smtptest.NewServer(t)
+	server.Inject(smtptest.Fault{Stage: smtptest.StageData, Drop: true})
+	campaignToSend := newCampaignToSend(t)
+
+	err := newSMTPMailer(server).Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.True(t, campaign.IsTemporary(err))
+}
+
+func Test_SMTPMailer_ConnectionDroppedOnQuit_MessageIsSent(t *testing.T) {
+	server := smtptest.NewServer(t)
+	server.Inject(smtptest.Fault{Stage: smtptest.StageQuit, Drop: true})
+	campaignToSend := newCampaignToSend(t)
+
+	err := newSMTPMailer(server).Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.Nil(t, err)
+	assert.Len(t, server.Messages(), 1)
+}
+
+func Test_SMTPMailer_SlowServer_TemporaryError(t *testing.T) {
+	server := smtptest.NewServer(t)
+	server.Inject(smtptest.Fault{Stage: smtptest.StageMessage, Delay: 300 * time.Millisecond})
+	campaignToSend := newCampaignToSend(t)
+	mailer := newSMTPMailer(server)
+	mailer.Timeout = 100 * time.Millisecond
+
+	err := mailer.Send(context.Background(), campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.True(t, campaign.IsTemporary(err))
+	assert.Empty(t, server.Messages())
+}
+
+func Test_SMTPMailer_ContextIsDone_StopWaitingForServer(t *testing.T) {
+	server := smtptest.NewServer(t)
+	server.Inject(smtptest.Fault{Stage: smtptest.StageMessage, Delay: time.Second})
+	campaignToSend := newCampaignToSend(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+
+	err := newSMTPMailer(server).Send(ctx, campaignToSend, &campaignToSend.Contacts[0])
+
+	assert.True(t, campaign.IsTemporary(err))
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+}
+
+func Test_SendEmailAndUpdateStatus_ThroughSmtp_RecordEachContact(t *testing.T) {
+	server := smtptest.NewServer(t)
+	server.RejectRecipient("b@test.com", 550, "mailbox unavailable")
+	campaignToSend, _ := campaign.NewCampaign("Campaign X", "Hi", "Hello", []campaign.Contact{
+		{Email: "a@test.com"},
+		{Email: "b@test.com"},
+	}, "teste@teste.com")
+	campaignToSend.Started()
+	repositoryMock := new(internalmock.CampaignRepositoryMock)
+	repositoryMock.On("GetDeliveries", mock.Anything, mock.Anything).Return([]campaign.Delivery{}, nil)
+	repositoryMock.On("SaveDelivery", mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("RenewLease", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("UpdateContact", mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("Release", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	service := campaign.ServiceImp{Repository: repositoryMock, Mailer: newSMTPMailer(server)}
+
+	service.SendEmailAndUpdateStatus(context.Background(), campaignToSend)
+
+	assert.Equal(t, campaign.Done, campaignToSend.Status)
+	assert.Equal(t, campaign.ContactSent, campaignToSend.Contacts[0].Status)
+	assert.Equal(t, campaign.ContactFailed, campaignToSend.Contacts[1].Status)
+	assert.Contains(t, campaignToSend.Contacts[1].Error, "mailbox unavailable")
+	assert.Len(t, server.Messages(), 1)
+}
+
+func Test_SendEmailAndUpdateStatus_ThroughSmtp_RetryGreylistedContact(t *testing.T) {
+	server := smtptest.NewServer(t)
+	server.Inject(smtptest.Fault{Stage: smtptest.StageRcpt, Code: 451, Text: "greylisted", Times: 1})
+	campaignToSend, _ := campaign.NewCampaign("Campaign X", "Hi", "Hello", []campaign.Contact{{Email: "a@test.com"}}, "teste@teste.com")
+	campaignToSend.Started()
+	repositoryMock := new(internalmock.CampaignRepositoryMock)
+	repositoryMock.On("GetDeliveries", mock.Anything, mock.Anything).Return([]campaign.Delivery{}, nil)
+	repositoryMock.On("SaveDelivery", mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("RenewLease", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("UpdateContact", mock.Anything, mock.Anything).Return(nil)
+	repositoryMock.On("Release", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	service := campaign.ServiceImp{
+		Repository:  repositoryMock,
+		Mailer:      newSMTPMailer(server),
+		RetryPolicy: campaign.RetryPolicy{MaxAttempts: 3},
+	}
+
+	service.SendEmailAndUpdateStatus(context.Background(), campaignToSend)
+	assert.Equal(t, campaign.ContactDeferred, campaignToSend.Contacts[0].Status)
+	assert.Equal(t, campaign.Started, campaignToSend.Status)
+
+	service.SendEmailAndUpdateStatus(context.Background(), campaignToSend)
+	assert.Equal(t, campaign.ContactSent, campaignToSend.Contacts[0].Status)
+	assert.Equal(t, campaign.Done, campaignToSend.Status)
+	assert.Len(t, server.Messages(), 1)
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// StdoutMailer prints each message instead of sending it.
+type StdoutMailer struct {
+	From string
+	// Writer defaults to os.Stdout.
+	Writer io.Writer
+
+	mutex sync.Mutex
+}
+
+func (m *StdoutMailer) Send(ctx context.Context, campaign *campaign.Campaign, contact *campaign.Contact) error {
+	message, err := newMessage(m.From, campaign, contact)
+	if err != nil {
+		return err
+	}
+
+	content, err := messageBytes(message)
+	if err != nil {
+		return err
+	}
+
+	writer := m.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, err = fmt.Fprintf(writer, "----- campaign %s to %s -----\n%s\n", campaign.ID, contact.Email, content)
+	return err
+}
@@ -0,0 +1,417 @@
+package memory
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	internalerrors "emailgo/internal/internal-errors"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CampaignRepository keeps campaigns in memory with the same behavior as
+// database.CampaignRepository, for tests and demos. The zero value is ready
+// to use and it is safe for concurrent use. Campaigns are copied in and out,
+// so callers never share state with the repository.
+type CampaignRepository struct {
+	mu         sync.Mutex
+	campaigns  map[string]*campaign.Campaign
+	deliveries map[string][]campaign.Delivery
+	attempts   []campaign.DeliveryAttempt
+}
+
+// lock fails like a query would when ctx is done, and otherwise locks the
+// repository, creating its maps on first use.
+func (c *CampaignRepository) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	if c.campaigns == nil {
+		c.campaigns = map[string]*campaign.Campaign{}
+		c.deliveries = map[string][]campaign.Delivery{}
+	}
+	return nil
+}
+
+func (c *CampaignRepository) Create(ctx context.Context, campaignToCreate *campaign.Campaign) error {
+	if err := c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	if _, found := c.campaigns[campaignToCreate.ID]; found {
+		return fmt.Errorf("campaign %s already exists", campaignToCreate.ID)
+	}
+	c.saveContacts(campaignToCreate)
+	c.campaigns[campaignToCreate.ID] = copyCampaign(campaignToCreate)
+	return nil
+}
+
+// Update saves the campaign with its contacts, dropping the contacts that
+// are no longer in it.
+func (c *CampaignRepository) Update(ctx context.Context, campaignToUpdate *campaign.Campaign) error {
+	if err := c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	if _, err := c.atVersion(campaignToUpdate); err != nil {
+		return err
+	}
+	campaignToUpdate.Version++
+	c.saveContacts(campaignToUpdate)
+	c.campaigns[campaignToUpdate.ID] = copyCampaign(campaignToUpdate)
+	return nil
+}
+
+// saveContacts links the contacts to their campaign and keeps their
+// delivery attempts, like the associations saved by GORM.
+func (c *CampaignRepository) saveContacts(campaignToSave *campaign.Campaign) {
+	for index := range campaignToSave.Contacts {
+		contact := &campaignToSave.Contacts[index]
+		contact.CampaignId = campaignToSave.ID
+		c.saveAttempts(contact)
+	}
+}
+
+func (c *CampaignRepository) saveAttempts(contact *campaign.Contact) {
+	for index := range contact.DeliveryAttempts {
+		attempt := &contact.DeliveryAttempts[index]
+		attempt.ContactId = contact.ID
+		if attempt.ID == 0 {
+			attempt.ID = uint(len(c.attempts) + 1)
+			c.attempts = append(c.attempts, *attempt)
+		} else {
+			c.attempts[attempt.ID-1] = *attempt
+		}
+	}
+}
+
+func (c *CampaignRepository) UpdateStatus(ctx context.Context, campaignToUpdate *campaign.Campaign) error {
+	if err := c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	saved, err := c.atVersion(campaignToUpdate)
+	if err != nil {
+		return err
+	}
+	copyStatus(saved, campaignToUpdate)
+	return nil
+}
+
+// atVersion returns the saved campaign when it is still at the version of
+// campaignToSave and ErrConflict otherwise.
+func (c *CampaignRepository) atVersion(campaignToSave *campaign.Campaign) (*campaign.Campaign, error) {
+	saved, found := c.campaigns[campaignToSave.ID]
+	if !found || saved.Version != campaignToSave.Version {
+		return nil, internalerrors.ErrConflict
+	}
+	return saved, nil
+}
+
+// copyStatus copies the columns UpdateStatus, Claim and Release save and
+// moves both campaigns to the next version.
+func copyStatus(saved *campaign.Campaign, from *campaign.Campaign) {
+	saved.Status = from.Status
+	saved.ScheduledFor = copyTime(from.ScheduledFor)
+	saved.SendAt = copyTime(from.SendAt)
+	saved.LeaseOwner = from.LeaseOwner
+	saved.LeaseExpiresOn = copyTime(from.LeaseExpiresOn)
+	saved.UpdatedOn = from.UpdatedOn
+	saved.Version = from.Version + 1
+	from.Version = saved.Version
+}
+
+func (c *CampaignRepository) UpdateContact(ctx context.Context, contact *campaign.Contact) error {
+	if err := c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	saved, found := c.campaigns[contact.CampaignId]
+	if !found {
+		return errors.New("campaign " + contact.CampaignId + " does not exist")
+	}
+
+	c.saveAttempts(contact)
+	for index := range saved.Contacts {
+		if saved.Contacts[index].ID == contact.ID {
+			saved.Contacts[index] = copyContact(*contact)
+			return nil
+		}
+	}
+	saved.Contacts = append(saved.Contacts, copyContact(*contact))
+	return nil
+}
+
+func (c *CampaignRepository) Get(ctx context.Context, filter campaign.ListFilter) ([]campaign.Campaign, error) {
+	if err := c.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	byName := filter.SortBy == campaign.SortByName
+	var after time.Time
+	if filter.After != nil && !byName {
+		var err error
+		after, err = time.Parse(time.RFC3339Nano, filter.After.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// compare orders a before b by the sort column and then by ID, the way
+	// the list is sorted in ascending order.
+	compare := func(a *campaign.Campaign, name string, createdOn time.Time, id string) int {
+		result := 0
+		if byName {
+			result = strings.Compare(a.Name, name)
+		} else {
+			result = a.CreatedOn.Compare(createdOn)
+		}
+		if result == 0 {
+			result = strings.Compare(a.ID, id)
+		}
+		if filter.Descending {
+			result = -result
+		}
+		return result
+	}
+
+	campaigns := []campaign.Campaign{}
+	for _, saved := range c.campaigns {
+		if filter.Status != "" && saved.Status != filter.Status {
+			continue
+		}
+		if filter.CreatedBy != "" && saved.CreatedBy != filter.CreatedBy {
+			continue
+		}
+		// The name is matched as typed, % and _ included, as the database
+		// does once it escapes them.
+		if filter.Name != "" && !strings.Contains(strings.ToLower(saved.Name), strings.ToLower(filter.Name)) {
+			continue
+		}
+		if filter.CreatedFrom != nil && saved.CreatedOn.Before(*filter.CreatedFrom) {
+			continue
+		}
+		if filter.CreatedTo != nil && saved.CreatedOn.After(*filter.CreatedTo) {
+			continue
+		}
+		if filter.After != nil && compare(saved, filter.After.Value, after, filter.After.ID) <= 0 {
+			continue
+		}
+		campaigns = append(campaigns, *copyCampaign(saved))
+	}
+
+	sort.Slice(campaigns, func(i, j int) bool {
+		return compare(&campaigns[i], campaigns[j].Name, campaigns[j].CreatedOn, campaigns[j].ID) < 0
+	})
+
+	return limited(campaigns, filter.Limit), nil
+}
+
+// limited keeps the first limit campaigns; a negative limit keeps them all.
+func limited(campaigns []campaign.Campaign, limit int) []campaign.Campaign {
+	if limit >= 0 && len(campaigns) > limit {
+		return campaigns[:limit]
+	}
+	return campaigns
+}
+
+// GetBy returns gorm.ErrRecordNotFound for an unknown campaign, as the
+// database repository does.
+func (c *CampaignRepository) GetBy(ctx context.Context, id string) (*campaign.Campaign, error) {
+	if err := c.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	saved, found := c.campaigns[id]
+	if !found {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return copyCampaign(saved), nil
+}
+
+func (c *CampaignRepository) Delete(ctx context.Context, campaignToDelete *campaign.Campaign) error {
+	if err := c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	if _, err := c.atVersion(campaignToDelete); err != nil {
+		return err
+	}
+	delete(c.campaigns, campaignToDelete.ID)
+	return nil
+}
+
+// Claim leases the due campaigns to owner, oldest update first. Holding the
+// lock while claiming keeps two workers from winning the same campaign.
+func (c *CampaignRepository) Claim(ctx context.Context, owner string, leaseUntil time.Time, limit int) ([]campaign.Campaign, error) {
+	if err := c.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	due := []*campaign.Campaign{}
+	for _, saved := range c.campaigns {
+		if isDue(saved, now) {
+			due = append(due, saved)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].UpdatedOn.Before(due[j].UpdatedOn)
+	})
+
+	claimed := []campaign.Campaign{}
+	for _, saved := range due {
+		if limit >= 0 && len(claimed) == limit {
+			break
+		}
+		candidate := copyCampaign(saved)
+		if candidate.Claim(owner, leaseUntil) != nil {
+			continue
+		}
+		copyStatus(saved, candidate)
+		claimed = append(claimed, *candidate)
+	}
+
+	return claimed, nil
+}
+
+func isDue(saved *campaign.Campaign, now time.Time) bool {
+	switch saved.Status {
+	case campaign.Started:
+		sendAt := saved.UpdatedOn
+		if saved.SendAt != nil {
+			sendAt = *saved.SendAt
+		}
+		return !sendAt.After(now)
+	case campaign.Scheduled:
+		return saved.ScheduledFor != nil && !saved.ScheduledFor.After(now)
+	case campaign.Sending:
+		return saved.LeaseExpiresOn != nil && saved.LeaseExpiresOn.Before(now)
+	}
+	return false
+}
+
+func (c *CampaignRepository) RenewLease(ctx context.Context, campaignToRenew *campaign.Campaign, owner string) error {
+	if err := c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	saved, err := c.leasedTo(campaignToRenew.ID, owner)
+	if err != nil {
+		return err
+	}
+	saved.LeaseExpiresOn = copyTime(campaignToRenew.LeaseExpiresOn)
+	return nil
+}
+
+func (c *CampaignRepository) Release(ctx context.Context, campaignToRelease *campaign.Campaign, owner string) error {
+	if err := c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	saved, err := c.leasedTo(campaignToRelease.ID, owner)
+	if err != nil || saved.Version != campaignToRelease.Version {
+		return campaign.ErrLeaseLost
+	}
+	copyStatus(saved, campaignToRelease)
+	return nil
+}
+
+func (c *CampaignRepository) leasedTo(id string, owner string) (*campaign.Campaign, error) {
+	saved, found := c.campaigns[id]
+	if !found || saved.Status != campaign.Sending || saved.LeaseOwner != owner {
+		return nil, campaign.ErrLeaseLost
+	}
+	return saved, nil
+}
+
+// Notify does nothing: workers using this repository find started campaigns
+// on their next poll.
+func (c *CampaignRepository) Notify(ctx context.Context, campaignToSend *campaign.Campaign) error {
+	return nil
+}
+
+func (c *CampaignRepository) GetDeliveries(ctx context.Context, campaignId string) ([]campaign.Delivery, error) {
+	if err := c.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	deliveries := []campaign.Delivery{}
+	for _, delivery := range c.deliveries[campaignId] {
+		delivery.FinishedOn = copyTime(delivery.FinishedOn)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func (c *CampaignRepository) SaveDelivery(ctx context.Context, delivery *campaign.Delivery) error {
+	if err := c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	saved := *delivery
+	saved.FinishedOn = copyTime(delivery.FinishedOn)
+
+	deliveries := c.deliveries[delivery.CampaignId]
+	for index := range deliveries {
+		if deliveries[index].ContactId == delivery.ContactId {
+			deliveries[index] = saved
+			return nil
+		}
+	}
+	c.deliveries[delivery.CampaignId] = append(deliveries, saved)
+	return nil
+}
+
+func copyCampaign(source *campaign.Campaign) *campaign.Campaign {
+	copied := *source
+	copied.ScheduledFor = copyTime(source.ScheduledFor)
+	copied.SendAt = copyTime(source.SendAt)
+	copied.LeaseExpiresOn = copyTime(source.LeaseExpiresOn)
+	copied.Contacts = make([]campaign.Contact, len(source.Contacts))
+	for index, contact := range source.Contacts {
+		copied.Contacts[index] = copyContact(contact)
+	}
+	return &copied
+}
+
+// copyContact leaves out the delivery attempts, which are not loaded with
+// the contacts.
+func copyContact(source campaign.Contact) campaign.Contact {
+	copied := source
+	copied.NextAttemptOn = copyTime(source.NextAttemptOn)
+	copied.DeliveryAttempts = nil
+	if source.Fields != nil {
+		copied.Fields = make(map[string]string, len(source.Fields))
+		for key, value := range source.Fields {
+			copied.Fields[key] = value
+		}
+	}
+	return copied
+}
+
+func copyTime(source *time.Time) *time.Time {
+	if source == nil {
+		return nil
+	}
+	copied := *source
+	return &copied
+}
@@ -0,0 +1,13 @@
+package memory
+
+import (
+	"emailgo/internal/domain/campaign"
+	"emailgo/internal/test/repositorytest"
+	"testing"
+)
+
+func Test_CampaignRepository_Contract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) campaign.Repository {
+		return &CampaignRepository{}
+	})
+}
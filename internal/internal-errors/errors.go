@@ -7,6 +7,15 @@ import (
 )
 
 var ErrInternal error = errors.New("Server internal error")
+var ErrForbidden error = errors.New("Forbidden")
+
+// ErrConflict is returned when a record was changed by someone else between
+// the moment it was read and the moment it was saved.
+var ErrConflict error = errors.New("Conflict: changed by another request")
+
+// ErrPreconditionFailed is returned when a record no longer is the version
+// the request expected, such as the one named in If-Match.
+var ErrPreconditionFailed error = errors.New("Precondition failed: version does not match")
 
 func ProcessErrorToReturn(err error) error {
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -1,12 +1,44 @@
 package internalerrors
 
 import (
-	"errors"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// FieldError is one rule a field failed. Field is the path of the field,
+// such as contacts[3].email, and Param the argument of the rule, such as
+// the 5 of min=5.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field of a struct that failed validation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error returns the messages of the failed fields, which for a single field
+// is its message alone.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for index, field := range e.Fields {
+		messages[index] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// NewFieldError returns a *ValidationError for a field that failed a rule
+// checked by hand rather than by a struct tag.
+func NewFieldError(field string, rule string, param string, message string) error {
+	return &ValidationError{Fields: []FieldError{{Field: field, Rule: rule, Param: param, Message: message}}}
+}
+
+// ValidateStruct returns a *ValidationError with every failed field, or nil
+// when obj is valid.
 func ValidateStruct(obj interface{}) error {
 	validate := validator.New()
 	err := validate.Struct(obj)
@@ -16,20 +48,38 @@ func ValidateStruct(obj interface{}) error {
 	}
 
 	validationErrors := err.(validator.ValidationErrors)
-	validationError := validationErrors[0]
+	fields := make([]FieldError, len(validationErrors))
+	for index, validationError := range validationErrors {
+		fields[index] = newFieldError(validationError)
+	}
+
+	return &ValidationError{Fields: fields}
+}
 
+func newFieldError(validationError validator.FieldError) FieldError {
 	field := strings.ToLower(validationError.StructField())
 
+	// The namespace starts with the name of the validated struct.
+	_, path, _ := strings.Cut(validationError.StructNamespace(), ".")
+
+	fieldError := FieldError{
+		Field: strings.ToLower(path),
+		Rule:  validationError.Tag(),
+		Param: validationError.Param(),
+	}
+
 	switch validationError.Tag() {
 	case "required":
-		return errors.New(field + " is required")
+		fieldError.Message = field + " is required"
 	case "max":
-		return errors.New(field + " is required with max " + validationError.Param())
+		fieldError.Message = field + " is required with max " + validationError.Param()
 	case "min":
-		return errors.New(field + " is required with min " + validationError.Param())
-	case "email":
-		return errors.New(field + " is invalid")
+		fieldError.Message = field + " is required with min " + validationError.Param()
+	case "oneof":
+		fieldError.Message = field + " must be one of " + validationError.Param()
+	default:
+		fieldError.Message = field + " is invalid"
 	}
 
-	return nil
+	return fieldError
 }
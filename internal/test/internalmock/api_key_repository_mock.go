@@ -0,0 +1,47 @@
+package internalmock
+
+import (
+	"context"
+	"emailgo/internal/domain/apikey"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ApiKeyRepositoryMock struct {
+	mock.Mock
+}
+
+func (r *ApiKeyRepositoryMock) Create(ctx context.Context, apiKey *apikey.ApiKey) error {
+	args := r.Called(ctx, apiKey)
+	return args.Error(0)
+}
+
+func (r *ApiKeyRepositoryMock) Update(ctx context.Context, apiKey *apikey.ApiKey) error {
+	args := r.Called(ctx, apiKey)
+	return args.Error(0)
+}
+
+func (r *ApiKeyRepositoryMock) UpdateLastUsed(ctx context.Context, apiKey *apikey.ApiKey) error {
+	args := r.Called(ctx, apiKey)
+	return args.Error(0)
+}
+
+func (r *ApiKeyRepositoryMock) GetBy(ctx context.Context, id string) (*apikey.ApiKey, error) {
+	args := r.Called(ctx, id)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*apikey.ApiKey), nil
+}
+
+func (r *ApiKeyRepositoryMock) GetByOwner(ctx context.Context, owner string) ([]apikey.ApiKey, error) {
+	args := r.Called(ctx, owner)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]apikey.ApiKey), nil
+}
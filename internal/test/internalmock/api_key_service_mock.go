@@ -0,0 +1,44 @@
+package internalmock
+
+import (
+	"context"
+	"emailgo/internal/contract"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ApiKeyServiceMock struct {
+	mock.Mock
+}
+
+func (r *ApiKeyServiceMock) Create(ctx context.Context, request contract.NewApiKeyRequest) (*contract.ApiKeyCreatedResponse, error) {
+	args := r.Called(request)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*contract.ApiKeyCreatedResponse), nil
+}
+
+func (r *ApiKeyServiceMock) List(ctx context.Context, owner string) ([]contract.ApiKeyResponse, error) {
+	args := r.Called(owner)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]contract.ApiKeyResponse), nil
+}
+
+func (r *ApiKeyServiceMock) Revoke(ctx context.Context, id string, owner string) error {
+	args := r.Called(id, owner)
+	return args.Error(0)
+}
+
+func (r *ApiKeyServiceMock) Authenticate(ctx context.Context, key string) (*contract.ApiKeyResponse, error) {
+	args := r.Called(key)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*contract.ApiKeyResponse), nil
+}
@@ -1,7 +1,9 @@
 package internalmock
 
 import (
+	"context"
 	"emailgo/internal/domain/campaign"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,23 +12,38 @@ type CampaignRepositoryMock struct {
 	mock.Mock
 }
 
-func (r *CampaignRepositoryMock) Create(campaign *campaign.Campaign) error {
-	args := r.Called(campaign)
+func (r *CampaignRepositoryMock) Create(ctx context.Context, campaign *campaign.Campaign) error {
+	args := r.Called(ctx, campaign)
 	return args.Error(0)
 }
 
-func (r *CampaignRepositoryMock) Update(campaign *campaign.Campaign) error {
-	args := r.Called(campaign)
+func (r *CampaignRepositoryMock) Update(ctx context.Context, campaign *campaign.Campaign) error {
+	args := r.Called(ctx, campaign)
 	return args.Error(0)
 }
 
-func (r *CampaignRepositoryMock) Get() ([]campaign.Campaign, error) {
-	//args := r.Called(campaign)
-	return nil, nil
+func (r *CampaignRepositoryMock) UpdateStatus(ctx context.Context, campaign *campaign.Campaign) error {
+	args := r.Called(ctx, campaign)
+	return args.Error(0)
+}
+
+func (r *CampaignRepositoryMock) UpdateContact(ctx context.Context, contact *campaign.Contact) error {
+	args := r.Called(ctx, contact)
+	return args.Error(0)
+}
+
+func (r *CampaignRepositoryMock) Get(ctx context.Context, filter campaign.ListFilter) ([]campaign.Campaign, error) {
+	args := r.Called(ctx, filter)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]campaign.Campaign), nil
 }
 
-func (r *CampaignRepositoryMock) GetBy(id string) (*campaign.Campaign, error) {
-	args := r.Called(id)
+func (r *CampaignRepositoryMock) GetBy(ctx context.Context, id string) (*campaign.Campaign, error) {
+	args := r.Called(ctx, id)
 
 	if args.Error(1) != nil {
 		return nil, args.Error(1)
@@ -35,13 +52,13 @@ func (r *CampaignRepositoryMock) GetBy(id string) (*campaign.Campaign, error) {
 	return args.Get(0).(*campaign.Campaign), nil
 }
 
-func (r *CampaignRepositoryMock) Delete(campaign *campaign.Campaign) error {
-	args := r.Called(campaign)
+func (r *CampaignRepositoryMock) Delete(ctx context.Context, campaign *campaign.Campaign) error {
+	args := r.Called(ctx, campaign)
 	return args.Error(0)
 }
 
-func (r *CampaignRepositoryMock) GetCampaignsToBeSent() ([]campaign.Campaign, error) {
-	args := r.Called()
+func (r *CampaignRepositoryMock) Claim(ctx context.Context, owner string, leaseUntil time.Time, limit int) ([]campaign.Campaign, error) {
+	args := r.Called(ctx, owner, leaseUntil, limit)
 
 	if args.Error(1) != nil {
 		return nil, args.Error(1)
@@ -49,3 +66,33 @@ func (r *CampaignRepositoryMock) GetCampaignsToBeSent() ([]campaign.Campaign, er
 
 	return args.Get(0).([]campaign.Campaign), nil
 }
+
+func (r *CampaignRepositoryMock) RenewLease(ctx context.Context, campaign *campaign.Campaign, owner string) error {
+	args := r.Called(ctx, campaign, owner)
+	return args.Error(0)
+}
+
+func (r *CampaignRepositoryMock) Release(ctx context.Context, campaign *campaign.Campaign, owner string) error {
+	args := r.Called(ctx, campaign, owner)
+	return args.Error(0)
+}
+
+func (r *CampaignRepositoryMock) Notify(ctx context.Context, campaign *campaign.Campaign) error {
+	args := r.Called(ctx, campaign)
+	return args.Error(0)
+}
+
+func (r *CampaignRepositoryMock) GetDeliveries(ctx context.Context, campaignId string) ([]campaign.Delivery, error) {
+	args := r.Called(ctx, campaignId)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]campaign.Delivery), nil
+}
+
+func (r *CampaignRepositoryMock) SaveDelivery(ctx context.Context, delivery *campaign.Delivery) error {
+	args := r.Called(ctx, delivery)
+	return args.Error(0)
+}
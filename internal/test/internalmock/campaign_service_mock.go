@@ -1,7 +1,9 @@
 package internalmock
 
 import (
+	"context"
 	"emailgo/internal/contract"
+	"emailgo/internal/domain/campaign"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,13 +12,22 @@ type CampaignServiceMock struct {
 	mock.Mock
 }
 
-func (r *CampaignServiceMock) Create(newCampaign contract.NewCampaignRequest) (string, error) {
+func (r *CampaignServiceMock) Create(ctx context.Context, newCampaign contract.NewCampaignRequest) (string, error) {
 	args := r.Called(newCampaign)
 	return args.String(0), args.Error(1)
 }
 
-func (r *CampaignServiceMock) GetBy(id string) (*contract.CampaignResponse, error) {
-	args := r.Called(id)
+func (r *CampaignServiceMock) List(ctx context.Context, request contract.ListCampaignsRequest, caller campaign.Caller) (*contract.CampaignListResponse, error) {
+	args := r.Called(request, caller)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*contract.CampaignListResponse), args.Error(1)
+}
+
+func (r *CampaignServiceMock) GetBy(ctx context.Context, id string, caller campaign.Caller) (*contract.CampaignResponse, error) {
+	args := r.Called(id, caller)
 
 	if args.Error(1) != nil {
 		return nil, args.Error(1)
@@ -24,12 +35,47 @@ func (r *CampaignServiceMock) GetBy(id string) (*contract.CampaignResponse, erro
 	return args.Get(0).(*contract.CampaignResponse), args.Error(1)
 }
 
-func (r *CampaignServiceMock) Delete(id string) error {
-	args := r.Called(id)
+func (r *CampaignServiceMock) Update(ctx context.Context, id string, request contract.UpdateCampaignRequest, caller campaign.Caller) error {
+	args := r.Called(id, request, caller)
+	return args.Error(0)
+}
+
+func (r *CampaignServiceMock) Delete(ctx context.Context, id string, caller campaign.Caller) error {
+	args := r.Called(id, caller)
+	return args.Error(0)
+}
+
+func (r *CampaignServiceMock) Start(ctx context.Context, id string, caller campaign.Caller) error {
+	args := r.Called(id, caller)
+	return args.Error(0)
+}
+
+func (r *CampaignServiceMock) Schedule(ctx context.Context, id string, request contract.ScheduleCampaignRequest, caller campaign.Caller) error {
+	args := r.Called(id, request, caller)
+	return args.Error(0)
+}
+
+func (r *CampaignServiceMock) Unschedule(ctx context.Context, id string, caller campaign.Caller) error {
+	args := r.Called(id, caller)
+	return args.Error(0)
+}
+
+func (r *CampaignServiceMock) Cancel(ctx context.Context, id string, caller campaign.Caller) error {
+	args := r.Called(id, caller)
+	return args.Error(0)
+}
+
+func (r *CampaignServiceMock) Pause(ctx context.Context, id string, caller campaign.Caller) error {
+	args := r.Called(id, caller)
+	return args.Error(0)
+}
+
+func (r *CampaignServiceMock) Resume(ctx context.Context, id string, caller campaign.Caller) error {
+	args := r.Called(id, caller)
 	return args.Error(0)
 }
 
-func (r *CampaignServiceMock) Start(id string) error {
-	args := r.Called(id)
+func (r *CampaignServiceMock) Undo(ctx context.Context, id string, caller campaign.Caller) error {
+	args := r.Called(id, caller)
 	return args.Error(0)
 }
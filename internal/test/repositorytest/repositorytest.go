@@ -0,0 +1,451 @@
+// Package repositorytest is the contract every campaign.Repository
+// implementation must honor. Implementations run it from their own tests
+// with Run, giving a fresh empty repository to each case.
+package repositorytest
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	internalerrors "emailgo/internal/internal-errors"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var ctx = context.Background()
+
+// NewRepository returns an empty repository for one test case.
+type NewRepository func(t *testing.T) campaign.Repository
+
+type contractCase struct {
+	name string
+	test func(t *testing.T, repository campaign.Repository)
+}
+
+var cases = []contractCase{
+	{"Create_GetBy_PreloadContacts", createGetByPreloadContacts},
+	{"GetBy_UnknownCampaign_ErrRecordNotFound", getByUnknownCampaign},
+	{"GetBy_ContextIsDone_Err", getByContextIsDone},
+	{"Update_SaveFieldsAndReplaceContacts", updateSaveFieldsAndReplaceContacts},
+	{"Update_VersionChanged_ErrConflict", updateVersionChanged},
+	{"UpdateStatus_SaveStatusOnly", updateStatusSaveStatusOnly},
+	{"UpdateStatus_VersionChanged_ErrConflict", updateStatusVersionChanged},
+	{"UpdateContact_SaveContact", updateContactSaveContact},
+	{"Get_FilterByStatusOwnerAndName", getFilter},
+	{"Get_FilterByNameWithWildcards_MatchThemAsTyped", getFilterByNameWithWildcards},
+	{"Get_SortByNameAndPageWithCursor", getSortAndPage},
+	{"Delete_GetBy_ErrRecordNotFound", deleteCampaign},
+	{"Delete_VersionChanged_ErrConflict", deleteVersionChanged},
+	{"Claim_LeaseDueCampaignsOnce", claimDueCampaignsOnce},
+	{"Claim_ExpiredLease_LeaseToAnotherOwner", claimExpiredLease},
+	{"Claim_KeepLimit", claimKeepLimit},
+	{"RenewLease_AnotherOwner_ErrLeaseLost", renewLeaseAnotherOwner},
+	{"Release_SaveStatusAndEndLease", releaseSaveStatus},
+	{"Release_ContactsDeferred_ClaimOnceTheyAreDue", releaseContactsDeferred},
+	{"SaveDelivery_ReplaceEntryOfContact", saveDeliveryReplaceEntry},
+}
+
+// Run runs the contract against the repositories newRepository returns.
+func Run(t *testing.T, newRepository NewRepository) {
+	for _, contractCase := range cases {
+		t.Run(contractCase.name, func(t *testing.T) {
+			contractCase.test(t, newRepository(t))
+		})
+	}
+}
+
+func newCampaign(t *testing.T, name string, createdBy string) *campaign.Campaign {
+	contacts := []campaign.Contact{
+		{Email: "ana@teste.com", FirstName: "Ana", Fields: map[string]string{"city": "Recife"}},
+		{Email: "bia@teste.com", FirstName: "Bia"},
+	}
+	created, err := campaign.NewCampaign(name, "Hi {{.FirstName}}", "Body Hi!", contacts, createdBy)
+	require.Nil(t, err)
+	return created
+}
+
+func create(t *testing.T, repository campaign.Repository, campaigns ...*campaign.Campaign) {
+	for _, campaignToCreate := range campaigns {
+		require.Nil(t, repository.Create(ctx, campaignToCreate))
+	}
+}
+
+// started starts a campaign and saves it, due right away unless sendAt is
+// given.
+func started(t *testing.T, repository campaign.Repository, campaignToStart *campaign.Campaign, sendAt time.Time) {
+	require.Nil(t, campaignToStart.Started())
+	campaignToStart.SendAt = &sendAt
+	require.Nil(t, repository.UpdateStatus(ctx, campaignToStart))
+}
+
+func ids(campaigns []campaign.Campaign) []string {
+	result := []string{}
+	for _, item := range campaigns {
+		result = append(result, item.ID)
+	}
+	return result
+}
+
+func createGetByPreloadContacts(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+
+	saved, err := repository.GetBy(ctx, created.ID)
+
+	require.Nil(t, err)
+	assert.Equal(t, created.Name, saved.Name)
+	assert.Equal(t, campaign.Pending, saved.Status)
+	assert.Equal(t, created.CreatedBy, saved.CreatedBy)
+	require.Len(t, saved.Contacts, 2)
+	emails := []string{saved.Contacts[0].Email, saved.Contacts[1].Email}
+	assert.ElementsMatch(t, []string{"ana@teste.com", "bia@teste.com"}, emails)
+	for _, contact := range saved.Contacts {
+		assert.Equal(t, created.ID, contact.CampaignId)
+		assert.Equal(t, campaign.ContactQueued, contact.Status)
+		if contact.Email == "ana@teste.com" {
+			assert.Equal(t, "Recife", contact.Fields["city"])
+		}
+	}
+}
+
+func getByUnknownCampaign(t *testing.T, repository campaign.Repository) {
+	_, err := repository.GetBy(ctx, "unknown")
+
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func getByContextIsDone(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err := repository.GetBy(canceled, created.ID)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func updateSaveFieldsAndReplaceContacts(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+
+	created.Name = "Campaign Y"
+	created.Contacts = []campaign.Contact{created.Contacts[1], {ID: "new-contact", Email: "carla@teste.com", Status: campaign.ContactQueued}}
+	require.Nil(t, repository.Update(ctx, created))
+
+	saved, err := repository.GetBy(ctx, created.ID)
+	require.Nil(t, err)
+	assert.Equal(t, "Campaign Y", saved.Name)
+	assert.Equal(t, 1, created.Version)
+	assert.Equal(t, 1, saved.Version)
+	emails := []string{}
+	for _, contact := range saved.Contacts {
+		emails = append(emails, contact.Email)
+	}
+	assert.ElementsMatch(t, []string{"bia@teste.com", "carla@teste.com"}, emails)
+}
+
+func updateVersionChanged(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+	stale, _ := repository.GetBy(ctx, created.ID)
+	created.Name = "Campaign Y"
+	require.Nil(t, repository.Update(ctx, created))
+
+	stale.Name = "Campaign Z"
+	stale.Contacts = stale.Contacts[:1]
+	err := repository.Update(ctx, stale)
+
+	assert.True(t, errors.Is(err, internalerrors.ErrConflict))
+	assert.Equal(t, 0, stale.Version)
+	saved, _ := repository.GetBy(ctx, created.ID)
+	assert.Equal(t, "Campaign Y", saved.Name)
+	assert.Len(t, saved.Contacts, 2)
+}
+
+func updateStatusSaveStatusOnly(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+
+	scheduledFor := time.Now().Add(time.Hour)
+	require.Nil(t, created.Schedule(scheduledFor))
+	created.Name = "Campaign Y"
+	err := repository.UpdateStatus(ctx, created)
+
+	require.Nil(t, err)
+	saved, _ := repository.GetBy(ctx, created.ID)
+	assert.Equal(t, campaign.Scheduled, saved.Status)
+	require.NotNil(t, saved.ScheduledFor)
+	assert.WithinDuration(t, scheduledFor, *saved.ScheduledFor, time.Millisecond)
+	assert.Equal(t, "Campaign X", saved.Name)
+	assert.Equal(t, 1, saved.Version)
+}
+
+func updateStatusVersionChanged(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+	stale, _ := repository.GetBy(ctx, created.ID)
+	require.Nil(t, created.Schedule(time.Now().Add(time.Hour)))
+	require.Nil(t, repository.UpdateStatus(ctx, created))
+	require.Nil(t, stale.Cancel())
+
+	err := repository.UpdateStatus(ctx, stale)
+
+	assert.True(t, errors.Is(err, internalerrors.ErrConflict))
+	saved, _ := repository.GetBy(ctx, created.ID)
+	assert.Equal(t, campaign.Scheduled, saved.Status)
+	assert.Equal(t, 1, saved.Version)
+}
+
+func updateContactSaveContact(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+
+	contact := created.Contacts[0]
+	contact.Failed(errors.New("mailbox unavailable"))
+	contact.Attempts = 1
+	contact.DeliveryAttempts = []campaign.DeliveryAttempt{{CampaignId: created.ID, ContactId: contact.ID, Attempt: 1, AttemptedOn: time.Now(), Code: 550}}
+	require.Nil(t, repository.UpdateContact(ctx, &contact))
+
+	saved, _ := repository.GetBy(ctx, created.ID)
+	require.Len(t, saved.Contacts, 2)
+	for _, savedContact := range saved.Contacts {
+		if savedContact.ID == contact.ID {
+			assert.Equal(t, campaign.ContactFailed, savedContact.Status)
+			assert.Equal(t, "mailbox unavailable", savedContact.Error)
+			assert.Equal(t, 1, savedContact.Attempts)
+		} else {
+			assert.Equal(t, campaign.ContactQueued, savedContact.Status)
+		}
+	}
+}
+
+func getFilter(t *testing.T, repository campaign.Repository) {
+	first := newCampaign(t, "Black Friday", "ana@teste.com")
+	second := newCampaign(t, "Christmas", "ana@teste.com")
+	third := newCampaign(t, "Black Week", "bia@teste.com")
+	create(t, repository, first, second, third)
+	require.Nil(t, second.Cancel())
+	require.Nil(t, repository.UpdateStatus(ctx, second))
+
+	byStatus, err := repository.Get(ctx, campaign.ListFilter{Status: campaign.Canceled, Limit: 10})
+	require.Nil(t, err)
+	assert.Equal(t, []string{second.ID}, ids(byStatus))
+
+	byOwner, _ := repository.Get(ctx, campaign.ListFilter{CreatedBy: "ana@teste.com", Limit: 10})
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, ids(byOwner))
+
+	byName, _ := repository.Get(ctx, campaign.ListFilter{Name: "black", Limit: 10})
+	assert.ElementsMatch(t, []string{first.ID, third.ID}, ids(byName))
+	for _, item := range byName {
+		assert.Len(t, item.Contacts, 2)
+	}
+}
+
+func getFilterByNameWithWildcards(t *testing.T, repository campaign.Repository) {
+	percent := newCampaign(t, "Sale 50% off", "ana@teste.com")
+	digits := newCampaign(t, "Sale 500 off", "ana@teste.com")
+	underscore := newCampaign(t, "Sale_one", "ana@teste.com")
+	letter := newCampaign(t, "Salexone", "ana@teste.com")
+	create(t, repository, percent, digits, underscore, letter)
+
+	byPercent, err := repository.Get(ctx, campaign.ListFilter{Name: "50%", Limit: 10})
+	require.Nil(t, err)
+	assert.Equal(t, []string{percent.ID}, ids(byPercent))
+
+	byUnderscore, _ := repository.Get(ctx, campaign.ListFilter{Name: "sale_", Limit: 10})
+	assert.Equal(t, []string{underscore.ID}, ids(byUnderscore))
+}
+
+func getSortAndPage(t *testing.T, repository campaign.Repository) {
+	charlie := newCampaign(t, "Charlie campaign", "ana@teste.com")
+	alpha := newCampaign(t, "Alpha campaign", "ana@teste.com")
+	bravo := newCampaign(t, "Bravo campaign", "ana@teste.com")
+	create(t, repository, charlie, alpha, bravo)
+
+	filter := campaign.ListFilter{SortBy: campaign.SortByName, Limit: 2}
+	page, err := repository.Get(ctx, filter)
+	require.Nil(t, err)
+	assert.Equal(t, []string{alpha.ID, bravo.ID}, ids(page))
+
+	filter.After = campaign.NewCursor(&page[1], campaign.SortByName)
+	page, _ = repository.Get(ctx, filter)
+	assert.Equal(t, []string{charlie.ID}, ids(page))
+
+	filter = campaign.ListFilter{SortBy: campaign.SortByName, Descending: true, Limit: 10}
+	page, _ = repository.Get(ctx, filter)
+	assert.Equal(t, []string{charlie.ID, bravo.ID, alpha.ID}, ids(page))
+}
+
+func deleteCampaign(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+
+	require.Nil(t, repository.Delete(ctx, created))
+
+	_, err := repository.GetBy(ctx, created.ID)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func deleteVersionChanged(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+	stale, _ := repository.GetBy(ctx, created.ID)
+	require.Nil(t, created.Cancel())
+	require.Nil(t, repository.UpdateStatus(ctx, created))
+
+	err := repository.Delete(ctx, stale)
+
+	assert.True(t, errors.Is(err, internalerrors.ErrConflict))
+	saved, err := repository.GetBy(ctx, created.ID)
+	require.Nil(t, err)
+	assert.Len(t, saved.Contacts, 2)
+}
+
+func claimDueCampaignsOnce(t *testing.T, repository campaign.Repository) {
+	due := newCampaign(t, "Due campaign", "ana@teste.com")
+	waiting := newCampaign(t, "Waiting campaign", "ana@teste.com")
+	scheduled := newCampaign(t, "Scheduled campaign", "ana@teste.com")
+	pending := newCampaign(t, "Pending campaign", "ana@teste.com")
+	create(t, repository, due, waiting, scheduled, pending)
+	started(t, repository, due, time.Now().Add(-time.Second))
+	started(t, repository, waiting, time.Now().Add(time.Hour))
+	require.Nil(t, scheduled.Schedule(time.Now().Add(time.Hour)))
+	past := time.Now().Add(-time.Second)
+	scheduled.ScheduledFor, scheduled.SendAt = &past, &past
+	require.Nil(t, repository.UpdateStatus(ctx, scheduled))
+
+	leaseUntil := time.Now().Add(time.Minute)
+	claimed, err := repository.Claim(ctx, "worker-1", leaseUntil, 10)
+
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{due.ID, scheduled.ID}, ids(claimed))
+	for _, item := range claimed {
+		assert.Equal(t, campaign.Sending, item.Status)
+		assert.Equal(t, "worker-1", item.LeaseOwner)
+		assert.Equal(t, 2, item.Version)
+		assert.Len(t, item.Contacts, 2)
+	}
+	saved, _ := repository.GetBy(ctx, due.ID)
+	assert.Equal(t, campaign.Sending, saved.Status)
+	assert.Equal(t, "worker-1", saved.LeaseOwner)
+
+	claimed, err = repository.Claim(ctx, "worker-2", leaseUntil, 10)
+	require.Nil(t, err)
+	assert.Empty(t, claimed)
+}
+
+func claimExpiredLease(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+	started(t, repository, created, time.Now().Add(-time.Second))
+	_, err := repository.Claim(ctx, "worker-1", time.Now().Add(-time.Second), 10)
+	require.Nil(t, err)
+
+	claimed, err := repository.Claim(ctx, "worker-2", time.Now().Add(time.Minute), 10)
+
+	require.Nil(t, err)
+	assert.Equal(t, []string{created.ID}, ids(claimed))
+	assert.Equal(t, "worker-2", claimed[0].LeaseOwner)
+}
+
+func claimKeepLimit(t *testing.T, repository campaign.Repository) {
+	for _, name := range []string{"Campaign A", "Campaign B", "Campaign C"} {
+		created := newCampaign(t, name, "ana@teste.com")
+		create(t, repository, created)
+		started(t, repository, created, time.Now().Add(-time.Second))
+	}
+
+	claimed, err := repository.Claim(ctx, "worker-1", time.Now().Add(time.Minute), 2)
+
+	require.Nil(t, err)
+	assert.Len(t, claimed, 2)
+}
+
+func renewLeaseAnotherOwner(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+	started(t, repository, created, time.Now().Add(-time.Second))
+	claimed, _ := repository.Claim(ctx, "worker-1", time.Now().Add(time.Minute), 10)
+	require.Len(t, claimed, 1)
+
+	assert.Nil(t, repository.RenewLease(ctx, &claimed[0], "worker-1"))
+	assert.True(t, errors.Is(repository.RenewLease(ctx, &claimed[0], "worker-2"), campaign.ErrLeaseLost))
+}
+
+func releaseSaveStatus(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+	started(t, repository, created, time.Now().Add(-time.Second))
+	claimed, _ := repository.Claim(ctx, "worker-1", time.Now().Add(time.Minute), 10)
+	require.Len(t, claimed, 1)
+	require.Nil(t, claimed[0].Release())
+
+	assert.True(t, errors.Is(repository.Release(ctx, &claimed[0], "worker-2"), campaign.ErrLeaseLost))
+	require.Nil(t, repository.Release(ctx, &claimed[0], "worker-1"))
+
+	saved, _ := repository.GetBy(ctx, created.ID)
+	assert.Equal(t, campaign.Started, saved.Status)
+	assert.Empty(t, saved.LeaseOwner)
+	assert.Nil(t, saved.LeaseExpiresOn)
+	assert.True(t, errors.Is(repository.Release(ctx, &claimed[0], "worker-1"), campaign.ErrLeaseLost))
+}
+
+func releaseContactsDeferred(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+	started(t, repository, created, time.Now().Add(-time.Second))
+	claimed, _ := repository.Claim(ctx, "worker-1", time.Now().Add(time.Minute), 10)
+	require.Len(t, claimed, 1)
+	nextAttemptOn := time.Now().Add(300 * time.Millisecond)
+	for index := range claimed[0].Contacts {
+		contact := &claimed[0].Contacts[index]
+		contact.Deferred(errors.New("451 try later"), nextAttemptOn.Add(time.Duration(index)*time.Hour))
+		require.Nil(t, repository.UpdateContact(ctx, contact))
+	}
+	require.Nil(t, claimed[0].Release())
+	require.Nil(t, repository.Release(ctx, &claimed[0], "worker-1"))
+
+	claimed, err := repository.Claim(ctx, "worker-1", time.Now().Add(time.Minute), 10)
+	require.Nil(t, err)
+	assert.Empty(t, claimed)
+	saved, _ := repository.GetBy(ctx, created.ID)
+	assert.Equal(t, 3, saved.Version)
+
+	time.Sleep(time.Until(nextAttemptOn))
+	claimed, err = repository.Claim(ctx, "worker-1", time.Now().Add(time.Minute), 10)
+	require.Nil(t, err)
+	assert.Equal(t, []string{created.ID}, ids(claimed))
+}
+
+func saveDeliveryReplaceEntry(t *testing.T, repository campaign.Repository) {
+	created := newCampaign(t, "Campaign X", "ana@teste.com")
+	create(t, repository, created)
+	delivery := campaign.Delivery{
+		CampaignId: created.ID,
+		ContactId:  created.Contacts[0].ID,
+		MessageId:  "<1@teste.com>",
+		Status:     campaign.DeliveryInFlight,
+		StartedOn:  time.Now(),
+	}
+	require.Nil(t, repository.SaveDelivery(ctx, &delivery))
+
+	finishedOn := time.Now()
+	delivery.Status = campaign.DeliveryAccepted
+	delivery.FinishedOn = &finishedOn
+	require.Nil(t, repository.SaveDelivery(ctx, &delivery))
+
+	deliveries, err := repository.GetDeliveries(ctx, created.ID)
+	require.Nil(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, campaign.DeliveryAccepted, deliveries[0].Status)
+	assert.Equal(t, "<1@teste.com>", deliveries[0].MessageId)
+	assert.NotNil(t, deliveries[0].FinishedOn)
+
+	others, _ := repository.GetDeliveries(ctx, "unknown")
+	assert.Empty(t, others)
+}
@@ -0,0 +1,112 @@
+package smtptest
+
+import (
+	"strings"
+	"time"
+)
+
+// Stage is the point of the SMTP transaction a fault applies to.
+type Stage string
+
+const (
+	// StageGreeting is the banner sent when a client connects.
+	StageGreeting Stage = "GREETING"
+	StageMail     Stage = "MAIL"
+	StageRcpt     Stage = "RCPT"
+	// StageData is the answer to the DATA command itself.
+	StageData Stage = "DATA"
+	// StageMessage is the answer after the message content was received,
+	// which is where servers usually accept or reject a message.
+	StageMessage Stage = "MESSAGE"
+	// StageQuit is the answer to QUIT, after the message was accepted.
+	StageQuit Stage = "QUIT"
+)
+
+// Fault changes how the server answers at a stage. A fault with a Code
+// answers with it instead of the normal reply, Delay waits before answering
+// and Drop closes the connection without answering.
+type Fault struct {
+	Stage Stage
+	// Recipient limits a RCPT fault to one address. Empty matches any.
+	Recipient string
+	Code      int
+	Text      string
+	Delay     time.Duration
+	Drop      bool
+	// Times is how many times the fault fires before it is removed. Zero
+	// means it never expires.
+	Times int
+}
+
+// Inject adds a fault. Faults are tried in the order they were injected and
+// only the first one matching a stage fires.
+func (s *Server) Inject(fault Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// Reject answers every command of the stage with the given code.
+func (s *Server) Reject(stage Stage, code int, text string) {
+	s.Inject(Fault{Stage: stage, Code: code, Text: text})
+}
+
+// RejectRecipient answers RCPT for one address with the given code.
+func (s *Server) RejectRecipient(recipient string, code int, text string) {
+	s.Inject(Fault{Stage: StageRcpt, Recipient: recipient, Code: code, Text: text})
+}
+
+// Reset removes every fault.
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = nil
+}
+
+func (s *Server) takeFault(stage Stage, recipient string) *Fault {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for index, fault := range s.faults {
+		if fault.Stage != stage {
+			continue
+		}
+		if fault.Recipient != "" && !strings.EqualFold(fault.Recipient, recipient) {
+			continue
+		}
+
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:index], s.faults[index+1:]...)
+			}
+		}
+		found := *fault
+		return &found
+	}
+	return nil
+}
+
+// reply answers a stage, applying the first matching fault, and reports
+// whether the session goes on.
+func (session *session) reply(stage Stage, recipient string, code int, text string) bool {
+	fault := session.server.takeFault(stage, recipient)
+	if fault == nil {
+		return session.write(code, text)
+	}
+
+	if fault.Delay > 0 {
+		time.Sleep(fault.Delay)
+	}
+	if fault.Drop {
+		return false
+	}
+	if fault.Code != 0 {
+		code, text = fault.Code, fault.Text
+	}
+	if !session.write(code, text) {
+		return false
+	}
+	// A rejected greeting means the server will not talk to the client.
+	return stage != StageGreeting || code < 400
+}
@@ -0,0 +1,342 @@
+// Package smtptest runs an in-process SMTP server for tests. It captures the
+// messages it accepts and can be told to answer with errors, answer slowly or
+// drop the connection at a given stage of the SMTP transaction.
+package smtptest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Message is a message accepted by the server.
+type Message struct {
+	Username string
+	From     string
+	To       []string
+	Data     []byte
+}
+
+type Server struct {
+	Host string
+	Port int
+
+	listener  net.Listener
+	tlsConfig *tls.Config
+	clientTLS *tls.Config
+
+	mutex    sync.Mutex
+	messages []Message
+	faults   []*Fault
+	wait     sync.WaitGroup
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewServer starts a server on a random local port without TLS. It is closed
+// when the test finishes.
+func NewServer(t testing.TB) *Server {
+	return newServer(t, nil, nil)
+}
+
+// NewTLSServer starts a server that offers STARTTLS with a self-signed
+// certificate. ClientTLSConfig returns a configuration that trusts it.
+func NewTLSServer(t testing.TB) *Server {
+	serverConfig, clientConfig, err := selfSignedTLS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newServer(t, serverConfig, clientConfig)
+}
+
+func newServer(t testing.TB, serverConfig, clientConfig *tls.Config) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := listener.Addr().(*net.TCPAddr)
+	s := &Server{
+		Host:      "127.0.0.1",
+		Port:      address.Port,
+		listener:  listener,
+		tlsConfig: serverConfig,
+		clientTLS: clientConfig,
+		conns:     map[net.Conn]struct{}{},
+	}
+
+	s.wait.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Addr returns the host:port the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// ClientTLSConfig returns a client configuration trusting the server
+// certificate, or nil when the server does not offer TLS.
+func (s *Server) ClientTLSConfig() *tls.Config {
+	if s.clientTLS == nil {
+		return nil
+	}
+	return s.clientTLS.Clone()
+}
+
+// Messages returns the messages accepted so far.
+func (s *Server) Messages() []Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Close stops the server and drops every open connection.
+func (s *Server) Close() {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.listener.Close()
+	s.wait.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wait.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		if s.closed {
+			s.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mutex.Unlock()
+
+		s.wait.Add(1)
+		go func() {
+			defer s.wait.Done()
+			s.handle(conn)
+			s.mutex.Lock()
+			delete(s.conns, conn)
+			s.mutex.Unlock()
+		}()
+	}
+}
+
+type session struct {
+	server   *Server
+	conn     net.Conn
+	text     *textproto.Conn
+	tls      bool
+	username string
+	from     string
+	to       []string
+	lastCode int
+}
+
+func (s *Server) handle(conn net.Conn) {
+	session := &session{server: s, conn: conn, text: textproto.NewConn(conn)}
+	defer func() { session.conn.Close() }()
+
+	if !session.reply(StageGreeting, "", 220, "smtptest ready") {
+		return
+	}
+
+	for {
+		line, err := session.text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, argument, _ := strings.Cut(line, " ")
+		if !session.command(strings.ToUpper(verb), argument) {
+			return
+		}
+	}
+}
+
+// command handles one command and reports whether the session goes on.
+func (session *session) command(verb, argument string) bool {
+	switch verb {
+	case "EHLO", "HELO":
+		extensions := []string{"smtptest", "8BITMIME", "AUTH PLAIN LOGIN"}
+		if session.server.tlsConfig != nil && !session.tls {
+			extensions = append(extensions, "STARTTLS")
+		}
+		return session.write(250, extensions...)
+	case "STARTTLS":
+		if session.server.tlsConfig == nil || session.tls {
+			return session.write(502, "STARTTLS not available")
+		}
+		if !session.write(220, "ready to start TLS") {
+			return false
+		}
+		conn := tls.Server(session.conn, session.server.tlsConfig)
+		if err := conn.Handshake(); err != nil {
+			return false
+		}
+		session.conn = conn
+		session.text = textproto.NewConn(conn)
+		session.tls = true
+		return true
+	case "AUTH":
+		return session.auth(argument)
+	case "MAIL":
+		session.from = address(argument)
+		session.to = nil
+		return session.reply(StageMail, session.from, 250, "sender ok")
+	case "RCPT":
+		recipient := address(argument)
+		if !session.reply(StageRcpt, recipient, 250, "recipient ok") {
+			return false
+		}
+		if last := session.lastCode; last >= 200 && last < 300 {
+			session.to = append(session.to, recipient)
+		}
+		return true
+	case "DATA":
+		return session.data()
+	case "RSET":
+		session.from, session.to = "", nil
+		return session.write(250, "reset")
+	case "NOOP":
+		return session.write(250, "ok")
+	case "QUIT":
+		session.reply(StageQuit, "", 221, "bye")
+		return false
+	default:
+		return session.write(502, "command not implemented")
+	}
+}
+
+func (session *session) auth(argument string) bool {
+	mechanism, initial, _ := strings.Cut(argument, " ")
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		if initial == "" {
+			if !session.write(334, "") {
+				return false
+			}
+			line, err := session.text.ReadLine()
+			if err != nil {
+				return false
+			}
+			initial = line
+		}
+		decoded, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			return session.write(501, "invalid credentials encoding")
+		}
+		parts := strings.Split(string(decoded), "\x00")
+		if len(parts) != 3 {
+			return session.write(501, "invalid credentials")
+		}
+		session.username = parts[1]
+	case "LOGIN":
+		username, ok := session.challenge("Username:")
+		if !ok {
+			return false
+		}
+		if _, ok = session.challenge("Password:"); !ok {
+			return false
+		}
+		session.username = username
+	default:
+		return session.write(504, "mechanism not supported")
+	}
+	return session.write(235, "authenticated")
+}
+
+func (session *session) challenge(prompt string) (string, bool) {
+	if !session.write(334, base64.StdEncoding.EncodeToString([]byte(prompt))) {
+		return "", false
+	}
+	line, err := session.text.ReadLine()
+	if err != nil {
+		return "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return "", false
+	}
+	return string(decoded), true
+}
+
+func (session *session) data() bool {
+	if len(session.to) == 0 {
+		return session.write(503, "no valid recipients")
+	}
+	if !session.reply(StageData, "", 354, "end data with <CR><LF>.<CR><LF>") {
+		return false
+	}
+	if session.lastCode != 354 {
+		return true
+	}
+
+	data, err := session.text.ReadDotBytes()
+	if err != nil {
+		return false
+	}
+
+	if !session.reply(StageMessage, "", 250, "message accepted") {
+		return false
+	}
+	if session.lastCode >= 200 && session.lastCode < 300 {
+		session.server.mutex.Lock()
+		session.server.messages = append(session.server.messages, Message{
+			Username: session.username,
+			From:     session.from,
+			To:       session.to,
+			Data:     data,
+		})
+		session.server.mutex.Unlock()
+	}
+	session.from, session.to = "", nil
+	return true
+}
+
+func (session *session) write(code int, lines ...string) bool {
+	session.lastCode = code
+	writer := bufio.NewWriter(session.conn)
+	for index, line := range lines {
+		separator := " "
+		if index < len(lines)-1 {
+			separator = "-"
+		}
+		writer.WriteString(strconv.Itoa(code) + separator + line + "\r\n")
+	}
+	return writer.Flush() == nil
+}
+
+// address extracts the address from "FROM:<a@b>" or "TO:<a@b> SIZE=10".
+func address(argument string) string {
+	_, value, _ := strings.Cut(argument, ":")
+	value = strings.TrimSpace(value)
+	if start := strings.Index(value, "<"); start >= 0 {
+		if end := strings.Index(value[start:], ">"); end >= 0 {
+			return value[start+1 : start+end]
+		}
+	}
+	value, _, _ = strings.Cut(value, " ")
+	return value
+}
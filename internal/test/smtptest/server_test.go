@@ -0,0 +1,110 @@
+package smtptest
+
+import (
+	"net/smtp"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendTo(s *Server, recipients ...string) error {
+	return smtp.SendMail(s.Addr(), nil, "from@test.com", recipients, []byte("Subject: test\r\n\r\nbody\r\n"))
+}
+
+func codeOf(err error) int {
+	if protocolError, ok := err.(*textproto.Error); ok {
+		return protocolError.Code
+	}
+	return 0
+}
+
+func Test_Server_CaptureMessage(t *testing.T) {
+	server := NewServer(t)
+
+	err := sendTo(server, "a@test.com", "b@test.com")
+
+	assert.Nil(t, err)
+	messages := server.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "from@test.com", messages[0].From)
+	assert.Equal(t, []string{"a@test.com", "b@test.com"}, messages[0].To)
+	assert.Contains(t, string(messages[0].Data), "body")
+}
+
+func Test_Server_RejectRecipient_OnlyThatRecipientFails(t *testing.T) {
+	server := NewServer(t)
+	server.RejectRecipient("b@test.com", 550, "mailbox unavailable")
+
+	errA := sendTo(server, "a@test.com")
+	errB := sendTo(server, "b@test.com")
+
+	assert.Nil(t, errA)
+	assert.Equal(t, 550, codeOf(errB))
+	assert.Len(t, server.Messages(), 1)
+}
+
+func Test_Server_RejectMessage_NothingCaptured(t *testing.T) {
+	server := NewServer(t)
+	server.Reject(StageMessage, 451, "try again later")
+
+	err := sendTo(server, "a@test.com")
+
+	assert.Equal(t, 451, codeOf(err))
+	assert.Empty(t, server.Messages())
+}
+
+func Test_Server_FaultWithTimes_ExpiresAfterFiring(t *testing.T) {
+	server := NewServer(t)
+	server.Inject(Fault{Stage: StageRcpt, Code: 421, Text: "greylisted", Times: 1})
+
+	first := sendTo(server, "a@test.com")
+	second := sendTo(server, "a@test.com")
+
+	assert.Equal(t, 421, codeOf(first))
+	assert.Nil(t, second)
+}
+
+func Test_Server_Drop_ClosesConnection(t *testing.T) {
+	server := NewServer(t)
+	server.Inject(Fault{Stage: StageData, Drop: true})
+
+	err := sendTo(server, "a@test.com")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, codeOf(err))
+	assert.Empty(t, server.Messages())
+}
+
+func Test_Server_Delay_SlowsReply(t *testing.T) {
+	server := NewServer(t)
+	server.Inject(Fault{Stage: StageMail, Delay: 100 * time.Millisecond})
+	start := time.Now()
+
+	err := sendTo(server, "a@test.com")
+
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func Test_TLSServer_StartTLSAndAuth(t *testing.T) {
+	server := NewTLSServer(t)
+	client, err := smtp.Dial(server.Addr())
+	assert.Nil(t, err)
+	defer client.Close()
+
+	assert.Nil(t, client.StartTLS(server.ClientTLSConfig()))
+	assert.Nil(t, client.Auth(smtp.PlainAuth("", "user", "secret", server.Host)))
+	assert.Nil(t, client.Mail("from@test.com"))
+	assert.Nil(t, client.Rcpt("a@test.com"))
+	writer, err := client.Data()
+	assert.Nil(t, err)
+	writer.Write([]byte("Subject: test\r\n\r\nbody\r\n"))
+	assert.Nil(t, writer.Close())
+	assert.Nil(t, client.Quit())
+
+	messages := server.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "user", messages[0].Username)
+}
@@ -0,0 +1,185 @@
+// Package worker runs the campaign senders: it claims due campaigns, queues
+// them and sends them with a fixed number of goroutines until it is told to
+// stop.
+package worker
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var ErrShutdownTimeout = errors.New("worker did not stop within the shutdown timeout")
+
+// Sender is the part of campaign.ServiceImp the worker uses.
+type Sender interface {
+	ClaimCampaignsToBeSent(ctx context.Context, limit int) ([]campaign.Campaign, error)
+	SendEmailAndUpdateStatus(ctx context.Context, campaign *campaign.Campaign) error
+	Checkpoint(ctx context.Context, campaign *campaign.Campaign) error
+}
+
+type Config struct {
+	// Concurrency is how many campaigns are sent at the same time.
+	Concurrency int
+	// QueueSize bounds how many claimed campaigns wait for a sender.
+	QueueSize int
+	// PollInterval is how long the worker waits between claims when it is
+	// not woken earlier.
+	PollInterval time.Duration
+	// ShutdownTimeout is how long in-flight campaigns may keep sending
+	// after a stop was asked before they are checkpointed.
+	ShutdownTimeout time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Concurrency:     4,
+		QueueSize:       16,
+		PollInterval:    10 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+type Worker struct {
+	Sender Sender
+	Config Config
+	// Logger defaults to the standard logger.
+	Logger *log.Logger
+
+	wakeOnce sync.Once
+	wake     chan struct{}
+}
+
+// Wake makes the worker claim campaigns right away instead of waiting for
+// the next poll. Wakes asked while a claim is pending are merged into it.
+func (w *Worker) Wake() {
+	select {
+	case w.wakeChannel() <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Worker) wakeChannel() chan struct{} {
+	w.wakeOnce.Do(func() {
+		w.wake = make(chan struct{}, 1)
+	})
+	return w.wake
+}
+
+// Run claims and sends campaigns until ctx is done. It then stops claiming,
+// checkpoints the campaigns still queued and gives the in-flight ones up to
+// ShutdownTimeout to finish before asking them to checkpoint too. It returns
+// ErrShutdownTimeout when the senders do not stop within a second
+// ShutdownTimeout; their leases then expire and another worker resumes them.
+func (w *Worker) Run(ctx context.Context) error {
+	config := w.config()
+	queue := make(chan campaign.Campaign, config.QueueSize)
+	sendCtx, cancelSends := context.WithCancel(context.Background())
+	defer cancelSends()
+
+	var senders sync.WaitGroup
+	for i := 0; i < config.Concurrency; i++ {
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			for campaignToSend := range queue {
+				w.send(ctx, sendCtx, &campaignToSend)
+			}
+		}()
+	}
+
+	w.poll(ctx, queue, config.PollInterval)
+	close(queue)
+	w.logger().Println("Stopping worker")
+
+	stopped := make(chan struct{})
+	go func() {
+		senders.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-time.After(config.ShutdownTimeout):
+	}
+
+	cancelSends()
+	select {
+	case <-stopped:
+		return nil
+	case <-time.After(config.ShutdownTimeout):
+		return ErrShutdownTimeout
+	}
+}
+
+// poll claims as many campaigns as the queue has room for, every interval
+// and whenever the worker is woken, until ctx is done.
+func (w *Worker) poll(ctx context.Context, queue chan campaign.Campaign, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if free := cap(queue) - len(queue); free > 0 {
+			campaigns, err := w.Sender.ClaimCampaignsToBeSent(ctx, free)
+			if err != nil {
+				w.logger().Println("Error claiming campaigns:", err)
+			}
+			for _, claimed := range campaigns {
+				queue <- claimed
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wakeChannel():
+		}
+	}
+}
+
+// send sends a queued campaign, or only checkpoints it when the worker is
+// already stopping.
+func (w *Worker) send(stopping context.Context, sendCtx context.Context, campaignToSend *campaign.Campaign) {
+	if stopping.Err() != nil {
+		if err := w.Sender.Checkpoint(sendCtx, campaignToSend); err != nil {
+			w.logger().Println("Campaign not checkpointed:", campaignToSend.ID, err)
+		}
+		return
+	}
+
+	if err := w.Sender.SendEmailAndUpdateStatus(sendCtx, campaignToSend); err != nil {
+		w.logger().Println("Campaign not sent:", campaignToSend.ID, err)
+		return
+	}
+	w.logger().Println("Campaign sent:", campaignToSend.ID)
+}
+
+func (w *Worker) config() Config {
+	config := w.Config
+	defaults := DefaultConfig()
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaults.Concurrency
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = defaults.ShutdownTimeout
+	}
+	return config
+}
+
+func (w *Worker) logger() *log.Logger {
+	if w.Logger == nil {
+		return log.Default()
+	}
+	return w.Logger
+}
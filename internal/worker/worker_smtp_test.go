@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	"emailgo/internal/infrastructure/mail"
+	"emailgo/internal/infrastructure/memory"
+	"emailgo/internal/test/smtptest"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Run_ThroughSmtp_RetryDeferredContactsAndFailRejectedOnes(t *testing.T) {
+	server := smtptest.NewServer(t)
+	server.Inject(smtptest.Fault{Stage: smtptest.StageRcpt, Recipient: "greylisted@test.com", Code: 451, Text: "greylisted", Times: 1})
+	server.RejectRecipient("rejected@test.com", 550, "mailbox unavailable")
+
+	ctx := context.Background()
+	repository := &memory.CampaignRepository{}
+	campaignToSend, err := campaign.NewCampaign("Campaign X", "Hi", "Hello", []campaign.Contact{
+		{Email: "sent@test.com"},
+		{Email: "greylisted@test.com"},
+		{Email: "rejected@test.com"},
+	}, "teste@teste.com")
+	require.Nil(t, err)
+	require.Nil(t, repository.Create(ctx, campaignToSend))
+	require.Nil(t, campaignToSend.Started())
+	require.Nil(t, repository.UpdateStatus(ctx, campaignToSend))
+
+	service := &campaign.ServiceImp{
+		Repository: repository,
+		Mailer: &mail.SMTPMailer{
+			Host:    server.Host,
+			Port:    server.Port,
+			From:    "from@test.com",
+			TLS:     mail.TLSNone,
+			Timeout: time.Second,
+		},
+		RetryPolicy: campaign.RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond},
+		WorkerID:    "worker-1",
+	}
+	worker := &Worker{
+		Sender: service,
+		Config: Config{Concurrency: 1, QueueSize: 1, PollInterval: 20 * time.Millisecond, ShutdownTimeout: time.Second},
+		Logger: log.New(io.Discard, "", 0),
+	}
+	runCtx, stop := context.WithCancel(ctx)
+	stopped := make(chan error)
+	go func() { stopped <- worker.Run(runCtx) }()
+
+	assert.Eventually(t, func() bool {
+		saved, err := repository.GetBy(ctx, campaignToSend.ID)
+		return err == nil && saved.Status == campaign.Done
+	}, 5*time.Second, 20*time.Millisecond)
+	stop()
+	require.Nil(t, <-stopped)
+
+	saved, err := repository.GetBy(ctx, campaignToSend.ID)
+	require.Nil(t, err)
+	statuses := map[string]string{}
+	attempts := map[string]int{}
+	for _, contact := range saved.Contacts {
+		statuses[contact.Email] = contact.Status
+		attempts[contact.Email] = contact.Attempts
+	}
+	assert.Equal(t, map[string]string{
+		"sent@test.com":       campaign.ContactSent,
+		"greylisted@test.com": campaign.ContactSent,
+		"rejected@test.com":   campaign.ContactFailed,
+	}, statuses)
+	assert.Equal(t, 2, attempts["greylisted@test.com"])
+	assert.Equal(t, 1, attempts["rejected@test.com"])
+	assert.Len(t, server.Messages(), 2)
+}
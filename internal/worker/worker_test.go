@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"context"
+	"emailgo/internal/domain/campaign"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// senderFake hands out the campaigns of claims, one slice per call, and runs
+// send for every campaign it is asked to send.
+type senderFake struct {
+	mutex        sync.Mutex
+	claims       [][]campaign.Campaign
+	limits       []int
+	sent         []string
+	checkpointed []string
+	send         func(ctx context.Context, campaign *campaign.Campaign) error
+}
+
+func (s *senderFake) ClaimCampaignsToBeSent(ctx context.Context, limit int) ([]campaign.Campaign, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.limits = append(s.limits, limit)
+	if len(s.claims) == 0 {
+		return nil, nil
+	}
+	claimed := s.claims[0]
+	s.claims = s.claims[1:]
+	return claimed, nil
+}
+
+func (s *senderFake) SendEmailAndUpdateStatus(ctx context.Context, campaignToSend *campaign.Campaign) error {
+	var err error
+	if s.send != nil {
+		err = s.send(ctx, campaignToSend)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err == nil {
+		s.sent = append(s.sent, campaignToSend.ID)
+	}
+	return err
+}
+
+func (s *senderFake) Checkpoint(ctx context.Context, campaignToSend *campaign.Campaign) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.checkpointed = append(s.checkpointed, campaignToSend.ID)
+	return nil
+}
+
+func (s *senderFake) result() ([]string, []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.sent...), append([]string(nil), s.checkpointed...)
+}
+
+func newWorker(sender *senderFake, config Config) *Worker {
+	return &Worker{Sender: sender, Config: config, Logger: log.New(io.Discard, "", 0)}
+}
+
+func campaigns(ids ...string) []campaign.Campaign {
+	result := make([]campaign.Campaign, len(ids))
+	for index, id := range ids {
+		result[index] = campaign.Campaign{ID: id}
+	}
+	return result
+}
+
+func Test_Run_SendCampaignsConcurrently(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+	sender := &senderFake{
+		claims: [][]campaign.Campaign{campaigns("1", "2")},
+		send: func(ctx context.Context, campaign *campaign.Campaign) error {
+			started.Done()
+			// Both campaigns only finish once both are being sent.
+			started.Wait()
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	worker := newWorker(sender, Config{Concurrency: 2, QueueSize: 4, PollInterval: time.Hour, ShutdownTimeout: time.Second})
+
+	go func() {
+		started.Wait()
+		cancel()
+	}()
+	err := worker.Run(ctx)
+
+	assert.Nil(t, err)
+	sent, _ := sender.result()
+	assert.ElementsMatch(t, []string{"1", "2"}, sent)
+}
+
+func Test_Run_ClaimOnlyWhatFitsInTheQueue(t *testing.T) {
+	sender := &senderFake{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	worker := newWorker(sender, Config{Concurrency: 1, QueueSize: 3, PollInterval: time.Hour, ShutdownTimeout: time.Second})
+
+	worker.Run(ctx)
+
+	assert.Equal(t, []int{3}, sender.limits)
+}
+
+func Test_Run_Stopping_CheckpointQueuedCampaigns(t *testing.T) {
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	sender := &senderFake{
+		claims: [][]campaign.Campaign{campaigns("1", "2", "3")},
+		send: func(ctx context.Context, campaign *campaign.Campaign) error {
+			cancel()
+			<-release
+			return nil
+		},
+	}
+	worker := newWorker(sender, Config{Concurrency: 1, QueueSize: 4, PollInterval: time.Hour, ShutdownTimeout: time.Second})
+
+	go func() {
+		<-ctx.Done()
+		close(release)
+	}()
+	err := worker.Run(ctx)
+
+	assert.Nil(t, err)
+	sent, checkpointed := sender.result()
+	assert.Equal(t, []string{"1"}, sent)
+	assert.Equal(t, []string{"2", "3"}, checkpointed)
+}
+
+func Test_Run_InFlightPastShutdownTimeout_CancelSend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sender := &senderFake{
+		claims: [][]campaign.Campaign{campaigns("1")},
+		send: func(sendCtx context.Context, campaign *campaign.Campaign) error {
+			cancel()
+			<-sendCtx.Done()
+			return sendCtx.Err()
+		},
+	}
+	worker := newWorker(sender, Config{Concurrency: 1, QueueSize: 1, PollInterval: time.Hour, ShutdownTimeout: 50 * time.Millisecond})
+	start := time.Now()
+
+	err := worker.Run(ctx)
+
+	assert.Nil(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	sent, _ := sender.result()
+	assert.Empty(t, sent)
+}
+
+func Test_Run_SenderIgnoresCancel_ErrShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	block := make(chan struct{})
+	defer close(block)
+	sender := &senderFake{
+		claims: [][]campaign.Campaign{campaigns("1")},
+		send: func(sendCtx context.Context, campaign *campaign.Campaign) error {
+			cancel()
+			<-block
+			return nil
+		},
+	}
+	worker := newWorker(sender, Config{Concurrency: 1, QueueSize: 1, PollInterval: time.Hour, ShutdownTimeout: 20 * time.Millisecond})
+
+	err := worker.Run(ctx)
+
+	assert.Equal(t, ErrShutdownTimeout, err)
+}
+
+func Test_Wake_ClaimBeforePollInterval(t *testing.T) {
+	claimed := make(chan struct{})
+	sender := &senderFake{
+		claims: [][]campaign.Campaign{nil, campaigns("1")},
+		send: func(ctx context.Context, campaign *campaign.Campaign) error {
+			close(claimed)
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	worker := newWorker(sender, Config{Concurrency: 1, QueueSize: 1, PollInterval: time.Hour, ShutdownTimeout: time.Second})
+
+	go func() {
+		// Let the first, empty claim happen before waking the worker.
+		for {
+			sender.mutex.Lock()
+			claims := len(sender.limits)
+			sender.mutex.Unlock()
+			if claims > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		worker.Wake()
+		<-claimed
+		cancel()
+	}()
+	err := worker.Run(ctx)
+
+	assert.Nil(t, err)
+	sent, _ := sender.result()
+	assert.Equal(t, []string{"1"}, sent)
+}